@@ -3,6 +3,7 @@ module github.com/go-beans/go
 go 1.26.2
 
 require (
+	github.com/expr-lang/expr v1.17.8
 	github.com/go-errr/go v1.0.13
 	github.com/go-external-config/go v1.0.40
 	github.com/go-jang/go v1.0.1
@@ -11,7 +12,6 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/expr-lang/expr v1.17.8 // indirect
 	github.com/magiconair/properties v1.18.11 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
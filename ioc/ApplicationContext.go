@@ -1,15 +1,20 @@
 package ioc
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
 	"os"
 	"os/signal"
 	"reflect"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -20,24 +25,75 @@ import (
 	"github.com/go-jang/go/lang"
 	"github.com/go-jang/go/util/collections"
 	"github.com/go-jang/go/util/concurrent"
+	"github.com/go-jang/go/util/optional"
 )
 
+// Opt-in fallback resolving ambiguous injection by matching bean name with field name
+const autowireByFieldNameProperty = "ioc.autowire-by-field-name"
+
+// Default factory timeout, like 10s. No timeout if not set
+const createTimeoutProperty = "ioc.create-timeout"
+
+// Default factory and PostConstruct retry policy, like 5 attempts with 1s backoff
+const retryAttemptsProperty = "ioc.retry.attempts"
+const retryBackoffProperty = "ioc.retry.backoff"
+
+// Delay before the replaced bean instance is stopped and destroyed, like 30s. Destroyed immediately if not set
+const replaceGracePeriodProperty = "ioc.replace-grace-period"
+
+// Async event listeners executor: workers, like 4, queue capacity, like 1000,
+// and overflow policy when the queue is full: block, caller-runs or discard
+const asyncEventsWorkersProperty = "ioc.async-events.workers"
+const asyncEventsCapacityProperty = "ioc.async-events.queue-capacity"
+const asyncEventsPolicyProperty = "ioc.async-events.overflow"
+const asyncEventsDrainTimeoutProperty = "ioc.async-events.drain-timeout"
+
+// Reaction on a shorter-lived bean injected into a longer-lived one: warn, fail or ignore. Default: warn
+const scopeMismatchProperty = "ioc.scope-mismatch"
+
+// Lifecycle events replayed to Sticky listeners of beans created after the event
+var stickyEventTypes = []reflect.Type{
+	lang.TypeOf[*ContextRefreshedEvent](),
+	lang.TypeOf[*ApplicationStartedEvent](),
+	lang.TypeOf[*ApplicationReadyEvent](),
+}
+
 var applicationContext atomic.Pointer[ApplicationContext]
 var applicationContextMu sync.Mutex
 
 type ApplicationContext struct {
 	context             context.Context
 	cancel              context.CancelFunc
-	registered          []BeanDefinition
+	definitions         []BeanDefinition
+	definitionsCount    atomic.Int32
+	registry            atomic.Pointer[beanRegistry]
+	registryMu          sync.Mutex
 	instantiated        []BeanDefinition
+	instantiatedMu      sync.Mutex
+	waitsFor            map[BeanDefinition]BeanDefinition
+	waitsForMu          sync.Mutex
 	started             []BeanDefinition
-	beans               map[reflect.Type][]BeanDefinition
-	named               map[string]BeanDefinition
-	eventListenersCache map[reflect.Type][]eventListener
+	lifecycleMu         sync.Mutex
+	retiring            map[uint64]func() // removed once destroyed after the grace period
+	retiringSeq         uint64
+	retiringMu          sync.Mutex
+	eventListenersCache atomic.Pointer[concurrent.HashMap[reflect.Type, []eventListener]]
+	functionListeners   []*functionListener
+	functionListenersMu sync.Mutex
+	publishObservers    []*func(published PublishedEvent)
+	publishObserversMu  sync.Mutex
+	stickyEvents        []any
+	stickyEventsMu      sync.Mutex
+	eventExecutor       *eventExecutor
+	multicaster         atomic.Pointer[eventInfrastructure[ApplicationEventMulticaster]]
+	eventErrorHandler   atomic.Pointer[eventInfrastructure[ErrorHandler]]
+	eventsDrained       bool
+	eventExecutorMu     sync.Mutex
 	refreshed           atomic.Bool
 	startTime           time.Time
 	servicesCount       atomic.Int32
 	closing             atomic.Bool
+	shutdown            chan struct{} // closed when closing starts, before ContextClosedEvent
 	exiting             atomic.Bool
 }
 
@@ -55,54 +111,123 @@ func applicationContextInstance() *ApplicationContext {
 func newApplicationContext() *ApplicationContext {
 	slog.Info(fmt.Sprintf("ioc.ApplicationContext: starting with PID %d", os.Getpid()))
 	context, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	return &ApplicationContext{
-		context:             context,
-		cancel:              cancel,
-		registered:          make([]BeanDefinition, 0),
-		instantiated:        make([]BeanDefinition, 0),
-		beans:               make(map[reflect.Type][]BeanDefinition),
-		named:               make(map[string]BeanDefinition),
-		eventListenersCache: make(map[reflect.Type][]eventListener),
-		startTime:           time.Now(),
+	applicationContext := &ApplicationContext{
+		context:      context,
+		cancel:       cancel,
+		definitions:  make([]BeanDefinition, 0),
+		instantiated: make([]BeanDefinition, 0),
+		waitsFor:     make(map[BeanDefinition]BeanDefinition),
+		retiring:     make(map[uint64]func()),
+		shutdown:     make(chan struct{}),
+		startTime:    time.Now(),
 	}
+	applicationContext.registry.Store(newBeanRegistry(nil))
+	applicationContext.eventListenersCache.Store(concurrent.NewHashMap[reflect.Type, []eventListener]())
+	return applicationContext
 }
 
 func (this *ApplicationContext) register(bean BeanDefinition) {
-	if env.MatchesProfiles(bean.getProfiles()...) {
-		if len(bean.getNames()) > 0 {
-			for _, name := range bean.getNames() {
-				_, ok := this.named[name]
-				lang.Assert(!ok, "Bean with name '%s' already registered", name)
-				this.named[name] = bean
-			}
-		}
-		this.beans[bean.getType()] = append(this.beans[bean.getType()], bean)
-		this.registered = append(this.registered, bean)
-		slog.Debug(fmt.Sprintf("ioc.ApplicationContext: registered %s", bean))
+	concurrent.Synchronized(&this.registryMu, func() {
+		this.definitions = append(this.definitions, bean)
+		this.definitionsCount.Store(int32(len(this.definitions)))
+	})
+}
+
+// currentRegistry filters bean definitions by active profiles. Profiles are
+// evaluated on refresh or first resolution rather than during package init,
+// and re-evaluated if active profiles change before the first bean is created.
+// Registration failures, like duplicate bean names, are rethrown.
+func (this *ApplicationContext) currentRegistry() *beanRegistry {
+	registry := this.evaluatedRegistry()
+	if registry.failure != nil {
+		panic(registry.failure)
 	}
+	return registry
+}
+
+// evaluatedRegistry never panics, registration failure is kept in the registry
+// for failure handlers like the condition evaluation report
+func (this *ApplicationContext) evaluatedRegistry() *beanRegistry {
+	if registry := this.registry.Load(); registry.isCurrent(int(this.definitionsCount.Load())) {
+		return registry
+	}
+	this.registryMu.Lock()
+	defer this.registryMu.Unlock()
+
+	registry := this.registry.Load()
+	if registry.isCurrent(len(this.definitions)) {
+		return registry
+	}
+	profiles := slices.Clone(env.ActiveProfiles())
+	if slices.Equal(registry.profiles, profiles) {
+		registry = registry.copy(profiles)
+	} else if this.refreshed.Load() || len(this.instantiatedBeans()) > 0 {
+		slog.Warn(fmt.Sprintf("ioc.ApplicationContext: active profiles changed to %v after beans were created, bean definitions are not re-evaluated", env.ActiveProfiles()))
+		registry = registry.copy(profiles)
+	} else {
+		registry = newBeanRegistry(profiles)
+	}
+	for _, bean := range this.definitions[registry.evaluated:] {
+		registry.accept(bean)
+	}
+	registry.evaluated = len(this.definitions)
+	this.registry.Store(registry)
+	return registry
+}
+
+func (this *ApplicationContext) instantiatedBeans() []BeanDefinition {
+	this.instantiatedMu.Lock()
+	defer this.instantiatedMu.Unlock()
+	return slices.Clone(this.instantiated)
 }
 
 func (this *ApplicationContext) bean(inject *InjectQualifier[any]) any {
+	requested := inject.t
 	defer err.Catch(func(e any) {
 		if inject.fieldName == "" {
 			panic(e)
 		} else {
-			panic(err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot inject dependency into field '%s' of type %s", inject.fieldName, inject.t), e))
+			panic(err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot inject dependency into field '%s' of type %s", inject.fieldName, requested), e))
 		}
 	})
+	if t, ok := providedType(inject.t); ok {
+		return this.provider(inject, t)
+	}
+	if t, ok := referencedType(inject.t); ok {
+		ref := *inject
+		ref.t = t
+		ref.ref = requested
+		inject = &ref
+	}
+	if t, ok := pooledType(inject.t); ok {
+		pooled := *inject
+		pooled.t = t
+		pooled.pooled = true
+		inject = &pooled
+	}
+	registry := this.currentRegistry()
 	if len(inject.name) > 0 {
-		bean, ok := this.named[inject.name]
+		bean, ok := registry.named[inject.name]
 		if !ok && inject.optional {
 			return nil
 		}
-		lang.Assert(ok, "No bean named '%s' found", inject.name)
-		return this.beanInstance(bean)
+		lang.Assert(ok, "No bean named '%s' found%s", inject.name, this.skippedHint(registry, func(outcome ConditionOutcome) bool {
+			return slices.Contains(outcome.Names, inject.name)
+		}))
+		return this.dependencyInstance(inject, bean)
 
 	} else if inject.t.Kind() == reflect.Slice {
 		elemType := inject.t.Elem()
-		orderedBeans := this.orderedBeanInstances(this.registered, func(bean BeanDefinition) bool {
-			return this.eligible(bean.getType(), elemType)
-		})
+		// pool scope beans are resolved as *Pool[T] only
+		candidate := func(bean BeanDefinition) bool {
+			return bean.getScope() != Pooled && this.eligible(bean.getType(), elemType)
+		}
+		for _, bean := range registry.registered {
+			if candidate(bean) && this.isScopeMismatch(inject, bean) {
+				this.scopeMismatch(inject, bean)
+			}
+		}
+		orderedBeans := this.orderedBeanInstances(inject.owner, registry.registered, candidate)
 		result := reflect.MakeSlice(inject.t, 0, 0)
 		for _, bean := range orderedBeans {
 			value := reflect.ValueOf(bean)
@@ -115,7 +240,7 @@ func (this *ApplicationContext) bean(inject *InjectQualifier[any]) any {
 		var candidates []BeanDefinition
 		var primaryCandidates []BeanDefinition
 
-		for t, beans := range this.beans {
+		for t, beans := range registry.beans {
 			if this.eligible(t, inject.t) {
 				candidates = append(candidates, beans...)
 				for _, bean := range beans {
@@ -128,41 +253,180 @@ func (this *ApplicationContext) bean(inject *InjectQualifier[any]) any {
 
 		lang.Assert(len(primaryCandidates) <= 1, "Multiple primary beans of type %v found. Use name qualifier.\n%v", inject.t, primaryCandidates)
 		if len(primaryCandidates) == 1 {
-			return this.beanInstance(primaryCandidates[0])
+			return this.dependencyInstance(inject, primaryCandidates[0])
 		} else {
 			if len(candidates) == 0 && inject.optional {
 				return nil
 			}
-			lang.Assert(len(candidates) > 0, "No bean of type %v found", inject.t)
+			lang.Assert(len(candidates) > 0, "No bean of type %v found%s", inject.t, this.skippedHint(registry, func(outcome ConditionOutcome) bool {
+				return this.eligible(outcome.Type, inject.t)
+			}))
+			if len(candidates) > 1 && inject.fieldName != "" && env.Value[bool]("${"+autowireByFieldNameProperty+":false}") {
+				if bean := this.candidateNamed(candidates, inject.fieldName[strings.LastIndex(inject.fieldName, ".")+1:]); bean != nil {
+					return this.dependencyInstance(inject, bean)
+				}
+			}
 			lang.Assert(len(candidates) <= 1, "Multiple beans of type %v found. Use name qualifier or mark one of the beans primary.\n%v", inject.t, candidates)
-			return this.beanInstance(candidates[0])
+			return this.dependencyInstance(inject, candidates[0])
+		}
+	}
+}
+
+// provider resolves the provided type on every call
+func (this *ApplicationContext) provider(inject *InjectQualifier[any], t reflect.Type) any {
+	target := InjectQualifier[any]{
+		fieldName: inject.fieldName,
+		t:         t,
+		name:      inject.name,
+		optional:  inject.optional,
+	}
+	return reflect.MakeFunc(inject.t, func([]reflect.Value) []reflect.Value {
+		bean := this.bean(&target)
+		if bean == nil {
+			return []reflect.Value{reflect.Zero(t)}
+		}
+		return []reflect.Value{reflect.ValueOf(bean)}
+	}).Interface()
+}
+
+// dependencyInstance substitutes the scoped proxy for a bean injected into a longer-lived owner
+func (this *ApplicationContext) dependencyInstance(inject *InjectQualifier[any], bean BeanDefinition) any {
+	if inject.ref != nil {
+		lang.Assert(bean.getScope() == Singleton, "Ref may be applied only to singleton bean, got %v", bean)
+		this.beanInstanceFor(inject.owner, bean)
+		return newRef(inject.ref, bean)
+	}
+	lang.Assert(!inject.pooled || bean.getScope() == Pooled, "Bean %v is not pool scoped", bean)
+	lang.Assert(inject.pooled || bean.getScope() != Pooled, "Bean %v is pool scoped, resolve *ioc.Pool[%v] instead", bean, bean.getType())
+	if this.isScopeMismatch(inject, bean) {
+		if proxy := bean.getScopedProxy(); proxy != nil {
+			return proxy
+		}
+		this.scopeMismatch(inject, bean)
+	}
+	return this.beanInstanceFor(inject.owner, bean)
+}
+
+func (this *ApplicationContext) isScopeMismatch(inject *InjectQualifier[any], bean BeanDefinition) bool {
+	return inject.owner != nil && bean.getScope().lifetime() < inject.owner.getScope().lifetime()
+}
+
+func (this *ApplicationContext) scopeMismatch(inject *InjectQualifier[any], bean BeanDefinition) {
+	message := fmt.Sprintf("%s bean %v injected into %s bean %v is never re-resolved. Inject ioc.Provider or define ScopedProxy",
+		bean.getScope(), bean, inject.owner.getScope(), inject.owner)
+	switch policy := env.Value[string]("${" + scopeMismatchProperty + ":warn}"); policy {
+	case "warn":
+		slog.Warn("ioc.ApplicationContext: " + message)
+	case "fail":
+		panic(err.NewIllegalStateException(message))
+	case "ignore":
+	default:
+		panic(err.NewIllegalArgumentException(fmt.Sprintf("Unsupported %s '%s', expected warn, fail or ignore", scopeMismatchProperty, policy)))
+	}
+}
+
+// skippedHint explains which skipped bean definitions could have satisfied the dependency
+func (this *ApplicationContext) skippedHint(registry *beanRegistry, filter func(ConditionOutcome) bool) string {
+	var b strings.Builder
+	for _, outcome := range registry.outcomes {
+		if !outcome.Matched && filter(outcome) {
+			fmt.Fprintf(&b, "\nSkipped %s: %s", outcome.Definition, outcome.Reason)
 		}
 	}
+	return b.String()
+}
+
+// Bean names are unique, so at most one candidate matches
+func (this *ApplicationContext) candidateNamed(candidates []BeanDefinition, name string) BeanDefinition {
+	for _, bean := range candidates {
+		if slices.Contains(bean.getNames(), name) {
+			return bean
+		}
+	}
+	return nil
 }
 
 func (this *ApplicationContext) beanInstance(bean BeanDefinition) any {
+	return this.beanInstanceFor(nil, bean)
+}
+
+// beanInstanceFor resolves the bean requested while the owner bean is being created
+func (this *ApplicationContext) beanInstanceFor(owner BeanDefinition, bean BeanDefinition) any {
 	defer err.Catch(func(e any) {
 		panic(err.NewRuntimeExceptionFrom(fmt.Sprintf("Error creating bean %v", bean), e))
 	})
 	for _, name := range bean.getDependsOn() {
-		bean, ok := this.named[name]
+		bean, ok := this.currentRegistry().named[name]
 		lang.Assert(ok, "No dependency bean named '%s' found", name)
 		this.beanInstance(bean)
 	}
 	if bean.getScope() == Singleton {
-		if bean.getInstance() == nil {
+		if instance := bean.getInitializedInstance(); instance != nil {
+			return instance
+		}
+		if owner != nil && owner.getScope() == Singleton {
+			if !this.waitFor(owner, bean) {
+				// circular reference, the bean is exposed early instead of waiting on its mutex
+				instance := bean.getInstance()
+				lang.Assert(instance != nil, "Bean %v is requested by its own factory", bean)
+				return instance
+			}
+			defer this.doneWaiting(owner)
+		}
+		var created bool
+		concurrent.Synchronized(bean.getMutex(), func() {
+			if bean.getInitializedInstance() == nil {
+				this.servicesCount.Add(1)
+				bean.instantiate()
+				concurrent.Synchronized(&this.instantiatedMu, func() {
+					this.instantiated = append(this.instantiated, bean)
+				})
+				this.eventListenersCache.Store(concurrent.NewHashMap[reflect.Type, []eventListener]())
+				created = true
+			}
+		})
+		if created {
+			this.replayStickyEvents(bean)
+		}
+		return bean.getInitializedInstance()
+	}
+	if bean.getScope() == Pooled {
+		if bean.getPool() == nil {
 			concurrent.Synchronized(bean.getMutex(), func() {
-				if bean.getInstance() == nil {
+				if bean.getPool() == nil {
 					this.servicesCount.Add(1)
-					bean.instantiate()
-					this.instantiated = append(this.instantiated, bean)
-					this.eventListenersCache = make(map[reflect.Type][]eventListener)
+					bean.newPool()
+					concurrent.Synchronized(&this.instantiatedMu, func() {
+						this.instantiated = append(this.instantiated, bean)
+					})
 				}
 			})
 		}
-		return bean.getInstance()
+		return bean.getPool()
 	}
-	return bean.instantiate()
+	return bean.newInstance()
+}
+
+// waitFor records that the creation of the owner waits for the bean, unless
+// the bean already waits for the owner, on this or another goroutine
+func (this *ApplicationContext) waitFor(owner BeanDefinition, bean BeanDefinition) bool {
+	var circular bool
+	concurrent.Synchronized(&this.waitsForMu, func() {
+		// the graph stays acyclic, edges closing a cycle are never recorded
+		for next := bean; next != nil && !circular; next = this.waitsFor[next] {
+			circular = next == owner
+		}
+		if !circular {
+			this.waitsFor[owner] = bean
+		}
+	})
+	return !circular
+}
+
+func (this *ApplicationContext) doneWaiting(owner BeanDefinition) {
+	concurrent.Synchronized(&this.waitsForMu, func() {
+		delete(this.waitsFor, owner)
+	})
 }
 
 func (this *ApplicationContext) eligible(registered, requested reflect.Type) bool {
@@ -171,6 +435,7 @@ func (this *ApplicationContext) eligible(registered, requested reflect.Type) boo
 
 func (this *ApplicationContext) refresh() {
 	defer err.Recover(func(e any) {
+		slog.Info(this.ConditionEvaluationReport().String())
 		this.exit1(e, "Context refresh failed.")
 	})
 	this.doRefresh()
@@ -179,7 +444,7 @@ func (this *ApplicationContext) refresh() {
 func (this *ApplicationContext) doRefresh() {
 	threshold := time.Now()
 	this.initializeBeans()
-	this.startLifecycleBeans()
+	concurrent.Synchronized(&this.lifecycleMu, this.startLifecycleBeans)
 	this.refreshed.Store(true)
 
 	slog.Info(fmt.Sprintf("ioc.ApplicationContext: context refreshed in %v", time.Since(threshold)))
@@ -187,18 +452,19 @@ func (this *ApplicationContext) doRefresh() {
 }
 
 func (this *ApplicationContext) initializeBeans() {
-	this.foreachBeanDefinition(this.registered, func(bean BeanDefinition) bool {
-		return bean.getScope() == Singleton && !bean.isLazy()
+	this.foreachBeanDefinition(this.currentRegistry().registered, func(bean BeanDefinition) bool {
+		return (bean.getScope() == Singleton || bean.getScope() == Pooled) && !bean.isLazy()
 	}, func(bean BeanDefinition) {
 		this.beanInstance(bean)
 	})
 }
 
+// Must be called holding lifecycleMu
 func (this *ApplicationContext) startLifecycleBeans() {
 	executor := concurrent.NewExecutor[BeanDefinition](runtime.NumCPU())
 	defer executor.Close()
 
-	phaseToBeans := this.phaseToLifecycleBeans(this.registered)
+	phaseToBeans := this.phaseToLifecycleBeans(this.currentRegistry().registered)
 	sortedPhases := make([]int, 0, len(phaseToBeans))
 	for phase := range phaseToBeans {
 		sortedPhases = append(sortedPhases, phase)
@@ -211,7 +477,7 @@ func (this *ApplicationContext) startLifecycleBeans() {
 		futures := make([]concurrent.Future[BeanDefinition], 0)
 		for _, bean := range beans {
 			futures = append(futures, executor.Submit(func() BeanDefinition {
-				this.beanInstance(bean).(Lifecycle).Start()
+				startLifecycle(this.beanInstance(bean))
 				return bean
 			}))
 		}
@@ -251,17 +517,12 @@ func (this *ApplicationContext) phaseToLifecycleBeans(beans []BeanDefinition) ma
 	return phaseToBeans
 }
 
-func (this *ApplicationContext) orderedBeanInstances(beans []BeanDefinition, filter func(b BeanDefinition) bool) []any {
+func (this *ApplicationContext) orderedBeanInstances(owner BeanDefinition, beans []BeanDefinition, filter func(b BeanDefinition) bool) []any {
 	orderToBeans := make(map[int][]any)
 	this.foreachBeanDefinition(beans, filter,
 		func(bean BeanDefinition) {
-			instance := this.beanInstance(bean)
-			order := math.MaxInt
-			if bean.getOrder() != nil {
-				order = *bean.getOrder()
-			} else if bean.isOrdered() {
-				order = instance.(Ordered).Order()
-			}
+			instance := this.beanInstanceFor(owner, bean)
+			order := beanOrder(bean, instance)
 			beans, ok := orderToBeans[order]
 			if !ok {
 				beans = make([]any, 0)
@@ -283,9 +544,20 @@ func (this *ApplicationContext) orderedBeanInstances(beans []BeanDefinition, fil
 	return orderedBeans
 }
 
+// beanOrder returns Order(...), Ordered or math.MaxInt
+func beanOrder(bean BeanDefinition, instance any) int {
+	if bean.getOrder() != nil {
+		return *bean.getOrder()
+	} else if bean.isOrdered() {
+		return instance.(Ordered).Order()
+	}
+	return math.MaxInt
+}
+
 func (this *ApplicationContext) run() {
 	defer err.Recover(func(e any) {
-		this.publishEvent(NewApplicationFailedEvent(e), true)
+		this.publishLifecycleEvent(NewApplicationFailedEvent(e))
+		slog.Info(this.ConditionEvaluationReport().String())
 		this.exit1(e, "Context run failed.")
 	})
 
@@ -298,7 +570,7 @@ func (this *ApplicationContext) run() {
 }
 
 func (this *ApplicationContext) executeApplicationRunnerBeans() {
-	orderedBeans := this.orderedBeanInstances(this.registered, func(bean BeanDefinition) bool {
+	orderedBeans := this.orderedBeanInstances(nil, this.currentRegistry().registered, func(bean BeanDefinition) bool {
 		return bean.isApplicationRunner()
 	})
 	for _, bean := range orderedBeans {
@@ -309,12 +581,16 @@ func (this *ApplicationContext) executeApplicationRunnerBeans() {
 func (this *ApplicationContext) close() {
 	concurrent.Synchronized(&applicationContextMu, func() {
 		if this.closing.CompareAndSwap(false, true) {
+			close(this.shutdown)
 			threshold := time.Now()
 			slog.Info(fmt.Sprintf("ioc.ApplicationContext: closing context with %d running services", this.servicesCount.Load()))
-			this.publishEvent(NewContextClosedEvent(), true)
+			this.publishLifecycleEvent(NewContextClosedEvent())
+			this.drainAsyncEvents()
+			this.closeSubscriptions()
 
 			this.cancel()
-			this.stopLifecycleBeans()
+			concurrent.Synchronized(&this.lifecycleMu, this.stopLifecycleBeans)
+			this.destroyRetired()
 			this.destroyBeans()
 
 			slog.Info(fmt.Sprintf("ioc.ApplicationContext: context closed in %v, uptime %v", time.Since(threshold), time.Since(this.startTime)))
@@ -348,19 +624,24 @@ func (this *ApplicationContext) exit1(e any, format string, a ...any) {
 }
 
 func (this *ApplicationContext) Start() {
-	if len(this.started) == 0 {
-		this.startLifecycleBeans()
-	}
+	concurrent.Synchronized(&this.lifecycleMu, func() {
+		if len(this.started) == 0 {
+			this.startLifecycleBeans()
+		}
+	})
 	this.PublishEvent(NewContextStartedEvent())
 }
 
 func (this *ApplicationContext) Stop() {
-	if len(this.started) > 0 {
-		this.stopLifecycleBeans()
-	}
-	this.publishEvent(NewContextStoppedEvent(), true)
+	concurrent.Synchronized(&this.lifecycleMu, func() {
+		if len(this.started) > 0 {
+			this.stopLifecycleBeans()
+		}
+	})
+	this.publishLifecycleEvent(NewContextStoppedEvent())
 }
 
+// Must be called holding lifecycleMu
 func (this *ApplicationContext) stopLifecycleBeans() {
 	executor := concurrent.NewExecutor[BeanDefinition](runtime.NumCPU())
 	defer executor.Close()
@@ -381,7 +662,7 @@ func (this *ApplicationContext) stopLifecycleBeans() {
 				defer err.Recover(func(e any) {
 					slog.Error(fmt.Sprintf("Could not stop Lifecycle bean %v. %s", bean, err.PrintStackTrace(e)))
 				})
-				bean.getInstance().(Lifecycle).Stop()
+				stopLifecycle(bean.getInstance())
 				return nil
 			}))
 		}
@@ -392,75 +673,399 @@ func (this *ApplicationContext) stopLifecycleBeans() {
 }
 
 func (this *ApplicationContext) destroyBeans() {
-	this.foreachBeanDefinition(collections.ReverseSlice(this.instantiated),
+	this.foreachBeanDefinition(collections.ReverseSlice(this.instantiatedBeans()),
 		func(bean BeanDefinition) bool { return bean.preDestroyEligible() },
 		func(bean BeanDefinition) {
 			bean.preDestroy()
 		})
 }
 
+// Replace the named singleton instance without restart, like a client with
+// rotated credentials. The new instance is used as is, without injection and
+// initialization callbacks, and is started if the context is running.
+// Ref holders observe the new instance immediately, beans injected with the
+// instance itself keep the old one. The old instance is stopped and destroyed
+// after ioc.replace-grace-period and BeanReplacedEvent is published.
+func (this *ApplicationContext) Replace(name string, instance any) {
+	bean := this.namedBean(name)
+	lang.Assert(bean.getScope() == Singleton, "Only singleton bean can be replaced, got %v", bean)
+	lang.Assert(instance != nil && reflect.TypeOf(instance).AssignableTo(bean.getType()), "Cannot replace bean %v with %T", bean, instance)
+	this.beanInstance(bean)
+
+	var old any
+	var started bool
+	concurrent.Synchronized(&this.lifecycleMu, func() {
+		started = slices.Contains(this.started, bean)
+		if started {
+			startLifecycle(instance)
+		}
+		old = bean.replace(instance)
+	})
+	this.eventListenersCache.Store(concurrent.NewHashMap[reflect.Type, []eventListener]())
+	this.retire(bean, old, started)
+	this.PublishEvent(NewBeanReplacedEvent(name, old, instance))
+}
+
+// retire stops and destroys the replaced instance after the grace period or on close
+func (this *ApplicationContext) retire(bean BeanDefinition, instance any, started bool) {
+	var once sync.Once
+	destroy := func() {
+		once.Do(func() {
+			if started {
+				func() {
+					defer err.Recover(func(e any) {
+						slog.Error(fmt.Sprintf("Could not stop Lifecycle bean %v. %s", bean, err.PrintStackTrace(e)))
+					})
+					stopLifecycle(instance)
+				}()
+			}
+			bean.destroy(instance)
+		})
+	}
+	var token uint64
+	concurrent.Synchronized(&this.retiringMu, func() {
+		this.retiringSeq++
+		token = this.retiringSeq
+		this.retiring[token] = destroy
+	})
+	time.AfterFunc(durationProperty(replaceGracePeriodProperty), func() {
+		concurrent.Synchronized(&this.retiringMu, func() {
+			delete(this.retiring, token)
+		})
+		destroy()
+	})
+}
+
+// destroyRetired destroys replaced instances whose grace period has not elapsed yet
+func (this *ApplicationContext) destroyRetired() {
+	var retiring map[uint64]func()
+	concurrent.Synchronized(&this.retiringMu, func() {
+		retiring, this.retiring = this.retiring, make(map[uint64]func())
+	})
+	for _, token := range slices.Sorted(maps.Keys(retiring)) {
+		retiring[token]()
+	}
+}
+
+// ConditionEvaluationReport returns outcomes of profile conditions evaluated
+// for every registered bean definition, including skipped ones
+func (this *ApplicationContext) ConditionEvaluationReport() ConditionEvaluationReport {
+	return ConditionEvaluationReport{
+		ActiveProfiles: slices.Clone(env.ActiveProfiles()),
+		Outcomes:       slices.Clone(this.evaluatedRegistry().outcomes),
+	}
+}
+
+// BeanDefinitions returns descriptors of all registered bean definitions
+// in registration order
+func (this *ApplicationContext) BeanDefinitions() []BeanDescriptor {
+	registered := this.currentRegistry().registered
+	descriptors := make([]BeanDescriptor, 0, len(registered))
+	for _, bean := range registered {
+		descriptors = append(descriptors, newBeanDescriptor(bean))
+	}
+	return descriptors
+}
+
+// BeanNamesForType returns names of beans assignable to the type, see BeanNamesForType[T]
+func (this *ApplicationContext) BeanNamesForType(t reflect.Type) []string {
+	names := make([]string, 0)
+	for _, bean := range this.currentRegistry().registered {
+		if this.eligible(bean.getType(), t) {
+			names = append(names, bean.getNames()...)
+		}
+	}
+	return names
+}
+
+// ContainsBean reports whether a bean with the name is registered
+func (this *ApplicationContext) ContainsBean(name string) bool {
+	_, ok := this.currentRegistry().named[name]
+	return ok
+}
+
+// IsSingleton reports whether the named bean is a singleton
+func (this *ApplicationContext) IsSingleton(name string) bool {
+	return this.namedBean(name).getScope() == Singleton
+}
+
+// TypeOf returns the registered type of the named bean
+func (this *ApplicationContext) TypeOf(name string) reflect.Type {
+	return this.namedBean(name).getType()
+}
+
+// BeanDefinition returns descriptor of the named bean
+func (this *ApplicationContext) BeanDefinition(name string) BeanDescriptor {
+	return newBeanDescriptor(this.namedBean(name))
+}
+
+func (this *ApplicationContext) namedBean(name string) BeanDefinition {
+	bean, ok := this.currentRegistry().named[name]
+	lang.Assert(ok, "No bean named '%s' found", name)
+	return bean
+}
+
+// OnEvent registers the function receiving events assignable to its argument
+// type, see ioc.OnEvent
+func (this *ApplicationContext) OnEvent(function any, options ...ListenerOption) {
+	listener := functionListenerMethod(function, options...)
+	concurrent.Synchronized(&this.functionListenersMu, func() {
+		this.functionListeners = append(this.functionListeners, &functionListener{method: listener})
+	})
+	this.eventListenersCache.Store(concurrent.NewHashMap[reflect.Type, []eventListener]())
+}
+
+// OnPublish registers the observer of every published event, see ioc.OnPublish
+func (this *ApplicationContext) OnPublish(observer func(published PublishedEvent)) func() {
+	registered := &observer
+	concurrent.Synchronized(&this.publishObserversMu, func() {
+		this.publishObservers = append(this.publishObservers, registered)
+	})
+	return func() {
+		concurrent.Synchronized(&this.publishObserversMu, func() {
+			this.publishObservers = slices.DeleteFunc(this.publishObservers, func(o *func(PublishedEvent)) bool {
+				return o == registered
+			})
+		})
+	}
+}
+
+// subscribe registers the channel sending function, close is called once by
+// the returned cancel or on context close, see ioc.Subscribe
+func (this *ApplicationContext) subscribe(send any, close func()) func() {
+	listener := &functionListener{method: functionListenerMethod(send), close: close}
+	concurrent.Synchronized(&this.functionListenersMu, func() {
+		this.functionListeners = append(this.functionListeners, listener)
+	})
+	this.eventListenersCache.Store(concurrent.NewHashMap[reflect.Type, []eventListener]())
+	return func() {
+		concurrent.Synchronized(&this.functionListenersMu, func() {
+			this.functionListeners = slices.DeleteFunc(this.functionListeners, func(l *functionListener) bool {
+				return l == listener
+			})
+		})
+		this.eventListenersCache.Store(concurrent.NewHashMap[reflect.Type, []eventListener]())
+		close()
+	}
+}
+
+// closeSubscriptions closes subscription channels after the last event
+func (this *ApplicationContext) closeSubscriptions() {
+	concurrent.Synchronized(&this.functionListenersMu, func() {
+		for _, listener := range this.functionListeners {
+			if listener.close != nil {
+				listener.close()
+			}
+		}
+	})
+}
+
+// publishLifecycleEvent logs failures, including unresolvable listeners, so
+// failure and shutdown handling always completes
+func (this *ApplicationContext) publishLifecycleEvent(event any) {
+	defer err.Catch(func(e any) {
+		slog.Error(fmt.Sprintf("ioc.ApplicationContext: cannot publish %T. %s", event, causeChain(e)))
+	})
+	this.publishEvent(context.Background(), event, LoggingErrorHandler{})
+}
+
 func (this *ApplicationContext) PublishEvent(event any) {
-	this.publishEvent(event, false)
+	this.publishEvent(context.Background(), event, nil)
 }
 
-func (this *ApplicationContext) publishEvent(event any, recoverPanic bool) {
-	eventType := reflect.TypeOf(event)
-	eventValue := reflect.ValueOf(event)
-	listeners := this.eventListeners(eventType)
+// PublishEventCtx publishes the event with the context passed to listeners
+// accepting (ctx context.Context, event E). Async listeners are skipped once
+// the context is done.
+func (this *ApplicationContext) PublishEventCtx(ctx context.Context, event any) {
+	this.publishEvent(ctx, event, nil)
+}
+
+// publishEvent multicasts the event, failures are handled by the error handler,
+// nil for the ErrorHandler bean
+func (this *ApplicationContext) publishEvent(ctx context.Context, event any, errorHandler ErrorHandler) {
+	if errorHandler == nil {
+		errorHandler = this.errorHandler()
+	}
+	var observers []*func(PublishedEvent)
+	concurrent.Synchronized(&this.publishObserversMu, func() {
+		observers = slices.Clone(this.publishObservers)
+	})
+	if len(observers) > 0 {
+		published := PublishedEvent{event, ctx, goroutineID()}
+		for _, observer := range observers {
+			(*observer)(published)
+		}
+	}
+	listeners := this.eventListeners(reflect.TypeOf(event))
+	invokers := make([]*ListenerInvoker, 0, len(listeners))
 	for _, listener := range listeners {
-		if recoverPanic {
-			this.notifyEventListener(listener.beanDefinition, listener.instance, listener.method, eventValue)
-		} else {
-			listener.method.invoke(listener.instance, eventValue)
+		invokers = append(invokers, &ListenerInvoker{this, ctx, listener, errorHandler})
+	}
+	this.eventMulticaster().MulticastEvent(event, invokers, errorHandler)
+	if slices.Contains(stickyEventTypes, reflect.TypeOf(event)) {
+		concurrent.Synchronized(&this.stickyEventsMu, func() {
+			this.stickyEvents = slices.DeleteFunc(this.stickyEvents, func(sticky any) bool {
+				return reflect.TypeOf(sticky) == reflect.TypeOf(event)
+			})
+			this.stickyEvents = append(this.stickyEvents, event)
+		})
+	}
+}
+
+// replayStickyEvents notifies Sticky listeners of the created bean about lifecycle events published before
+func (this *ApplicationContext) replayStickyEvents(bean BeanDefinition) {
+	var events []any
+	concurrent.Synchronized(&this.stickyEventsMu, func() {
+		events = slices.Clone(this.stickyEvents)
+	})
+	order := beanOrder(bean, bean.getInstance())
+	for _, event := range events {
+		invokers := make([]*ListenerInvoker, 0)
+		for _, method := range bean.getEventListenerMethods(reflect.TypeOf(event)) {
+			if method.sticky {
+				listener := eventListener{beanDefinition: bean, instance: bean.getInstance(), method: method, order: method.orderOr(order)}
+				invokers = append(invokers, &ListenerInvoker{this, context.Background(), listener, LoggingErrorHandler{}})
+			}
+		}
+		slices.SortStableFunc(invokers, func(a, b *ListenerInvoker) int {
+			return cmp.Compare(a.listener.order, b.listener.order)
+		})
+		if len(invokers) > 0 {
+			this.eventMulticaster().MulticastEvent(event, invokers, LoggingErrorHandler{})
 		}
 	}
 }
 
-func (this *ApplicationContext) notifyEventListener(bean BeanDefinition, instance any, method eventListenerMethod, eventValue reflect.Value) {
-	defer err.Recover(func(e any) {
-		slog.Error(fmt.Sprintf("Notify failed for bean %v. %s", bean, err.PrintStackTrace(e)))
+func (this *ApplicationContext) eventMulticaster() ApplicationEventMulticaster {
+	return resolveEventInfrastructure(this, &this.multicaster, ApplicationEventMulticasterBeanName, ApplicationEventMulticaster(SimpleApplicationEventMulticaster{}))
+}
+
+func (this *ApplicationContext) errorHandler() ErrorHandler {
+	return resolveEventInfrastructure(this, &this.eventErrorHandler, EventErrorHandlerBeanName, ErrorHandler(PropagatingErrorHandler{}))
+}
+
+// Event infrastructure bean resolved for a registry snapshot
+type eventInfrastructure[T any] struct {
+	registry *beanRegistry
+	instance T
+}
+
+// resolveEventInfrastructure looks up the bean overriding the default by its
+// reserved name once per registry snapshot rather than on every publish
+func resolveEventInfrastructure[T any](context *ApplicationContext, resolved *atomic.Pointer[eventInfrastructure[T]], name string, defaultInstance T) T {
+	registry := context.currentRegistry()
+	if current := resolved.Load(); current != nil && current.registry == registry {
+		return current.instance
+	}
+	instance := defaultInstance
+	if bean, ok := registry.named[name]; ok {
+		override, ok := context.beanInstance(bean).(T)
+		lang.Assert(ok, "Bean named '%s' must implement %v, got %v", name, lang.TypeOf[T](), bean.getType())
+		instance = override
+	}
+	resolved.Store(&eventInfrastructure[T]{registry, instance})
+	return instance
+}
+
+func (this *ApplicationContext) asyncEventExecutor() *eventExecutor {
+	var executor *eventExecutor
+	concurrent.Synchronized(&this.eventExecutorMu, func() {
+		if this.eventExecutor == nil && !this.eventsDrained {
+			this.eventExecutor = newEventExecutor(
+				env.Value[int]("${"+asyncEventsWorkersProperty+":"+strconv.Itoa(runtime.NumCPU())+"}"),
+				env.Value[int]("${"+asyncEventsCapacityProperty+":1000}"),
+				env.Value[string]("${"+asyncEventsPolicyProperty+":"+blockPolicy+"}"))
+		}
+		executor = this.eventExecutor
 	})
-	method.invoke(instance, eventValue)
+	return executor
+}
+
+// drainAsyncEvents delivers accepted async events, later events are delivered synchronously
+func (this *ApplicationContext) drainAsyncEvents() {
+	var executor *eventExecutor
+	concurrent.Synchronized(&this.eventExecutorMu, func() {
+		this.eventsDrained = true
+		executor = this.eventExecutor
+	})
+	if executor != nil {
+		executor.drain(durationPropertyOr(asyncEventsDrainTimeoutProperty, "30s"))
+	}
 }
 
 func (this *ApplicationContext) eventListeners(eventType reflect.Type) []eventListener {
-	listeners, ok := this.eventListenersCache[eventType]
-	if ok {
+	// cache replaced on bean instantiation, listeners resolved for a stale cache are discarded with it
+	cache := this.eventListenersCache.Load()
+	listeners := cache.Get(eventType)
+	if listeners != nil {
 		return listeners
 	}
-
-	listeners = this.resolveEventListeners(eventType)
-	this.eventListenersCache[eventType] = listeners
-	return listeners
+	return cache.PutIfAbsent(eventType, this.resolveEventListeners(eventType))
 }
 
 func (this *ApplicationContext) resolveEventListeners(eventType reflect.Type) []eventListener {
-	listenerMethodsByBean := make(map[any][]eventListenerMethod)
-	definitionByBean := make(map[any]BeanDefinition)
+	beans := this.instantiatedBeans()
+	for _, bean := range this.currentRegistry().registered {
+		if bean.getScope() == Singleton && bean.getInstance() == nil &&
+			slices.ContainsFunc(bean.getEventListenerMethods(eventType), func(method eventListenerMethod) bool { return method.instantiate }) {
+			beans = append(beans, bean)
+		}
+	}
 
-	orderedBeans := this.orderedBeanInstances(this.instantiated, func(bean BeanDefinition) bool {
-		instance := bean.getInstance()
-		methods := bean.getEventListenerMethods(eventType)
-		definitionByBean[instance] = bean
-		listenerMethodsByBean[instance] = methods
-		return len(methods) > 0
+	// beans are created by ordering, listeners of created beans collected afterwards
+	orderedBeans := this.orderedBeanInstances(nil, beans, func(bean BeanDefinition) bool {
+		return len(bean.getEventListenerMethods(eventType)) > 0
 	})
+	listenerMethodsByBean := make(map[any][]eventListenerMethod)
+	definitionByBean := make(map[any]BeanDefinition)
+	for _, bean := range beans {
+		if methods := bean.getEventListenerMethods(eventType); len(methods) > 0 {
+			definitionByBean[bean.getInstance()] = bean
+			listenerMethodsByBean[bean.getInstance()] = methods
+		}
+	}
 
 	listeners := make([]eventListener, 0)
 
 	for _, instance := range orderedBeans {
+		order := beanOrder(definitionByBean[instance], instance)
 		for _, method := range listenerMethodsByBean[instance] {
 			listeners = append(listeners, eventListener{
 				beanDefinition: definitionByBean[instance],
 				instance:       instance,
 				method:         method,
+				order:          method.orderOr(order),
 			})
 		}
 	}
+	concurrent.Synchronized(&this.functionListenersMu, func() {
+		for _, listener := range this.functionListeners {
+			if method := listener.method; eventType.AssignableTo(method.eventType) {
+				listeners = append(listeners, eventListener{
+					instance: method.method.Interface(),
+					method:   method,
+					order:    method.orderOr(math.MaxInt),
+				})
+			}
+		}
+	})
 
+	// ListenerOrder merges listeners across beans, equal orders keep bean order
+	slices.SortStableFunc(listeners, func(a, b eventListener) int {
+		return cmp.Compare(a.order, b.order)
+	})
 	return listeners
 }
 
+func durationProperty(key string) time.Duration {
+	return durationPropertyOr(key, "0s")
+}
+
+func durationPropertyOr(key string, defaultValue string) time.Duration {
+	value := env.Value[string]("${" + key + ":" + defaultValue + "}")
+	return optional.OfCommaErr(time.ParseDuration(value)).OrElsePanic("Cannot parse %s=%s as duration", key, value)
+}
+
 func (this *ApplicationContext) foreachBeanDefinition(beans []BeanDefinition, filter func(b BeanDefinition) bool, do func(BeanDefinition)) {
 	for _, bean := range beans {
 		if filter(bean) {
@@ -473,4 +1078,5 @@ type eventListener struct {
 	beanDefinition BeanDefinition
 	instance       any
 	method         eventListenerMethod
+	order          int // ListenerOrder or bean order
 }
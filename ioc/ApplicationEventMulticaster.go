@@ -0,0 +1,36 @@
+package ioc
+
+// Bean name of the ApplicationEventMulticaster overriding the default
+const ApplicationEventMulticasterBeanName = "applicationEventMulticaster"
+
+// Delivers published events to the listeners resolved for the event type.
+// Register a bean named ApplicationEventMulticasterBeanName to choose the
+// dispatch strategy, like a goroutine per listener:
+//
+//	func (this *GoMulticaster) MulticastEvent(event any, listeners []*ioc.ListenerInvoker, errorHandler ioc.ErrorHandler) {
+//		for _, listener := range listeners {
+//			go func() {
+//				if e := listener.Invoke(event); e != nil {
+//					errorHandler.HandleError(e)
+//				}
+//			}()
+//		}
+//	}
+type ApplicationEventMulticaster interface {
+	MulticastEvent(event any, listeners []*ListenerInvoker, errorHandler ErrorHandler)
+}
+
+// Default ApplicationEventMulticaster. Invokes listeners on the publisher
+// goroutine in order, Async listeners on the container-managed executor.
+type SimpleApplicationEventMulticaster struct{}
+
+// Implements ApplicationEventMulticaster
+func (this SimpleApplicationEventMulticaster) MulticastEvent(event any, listeners []*ListenerInvoker, errorHandler ErrorHandler) {
+	for _, listener := range listeners {
+		if listener.Async() {
+			listener.invokeAsync(event)
+		} else if e := listener.Invoke(event); e != nil {
+			errorHandler.HandleError(e)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-jang/go/lang"
+)
+
+// Implemented by beans receiving events assignable to E. Detected on any
+// registered bean, no EventListener registration is required:
+//
+//	func (this *Metrics) OnApplicationEvent(event *ioc.ApplicationReadyEvent) { ... }
+type ApplicationListener[E any] interface {
+	OnApplicationEvent(event E)
+}
+
+const applicationListenerMethodName = "OnApplicationEvent"
+
+// applicationListenerMethod detects ApplicationListener[E] implemented by the bean type
+func applicationListenerMethod(t reflect.Type) (eventListenerMethod, bool) {
+	method, ok := t.MethodByName(applicationListenerMethodName)
+	if !ok {
+		return eventListenerMethod{}, false
+	}
+	function := method.Func
+	if t.Kind() == reflect.Interface {
+		// interface methods have no receiver argument
+		in := []reflect.Type{t}
+		for i := 0; i < method.Type.NumIn(); i++ {
+			in = append(in, method.Type.In(i))
+		}
+		function = reflect.MakeFunc(reflect.FuncOf(in, nil, false), func(args []reflect.Value) []reflect.Value {
+			return args[0].MethodByName(applicationListenerMethodName).Call(args[1:])
+		})
+	}
+	if function.Type().NumIn() != 2 || function.Type().NumOut() != 0 {
+		return eventListenerMethod{}, false
+	}
+	return eventListenerMethod{
+		eventType: function.Type().In(1),
+		method:    function,
+	}, true
+}
+
+// functionListenerMethod adapts func(E) or func(context.Context, E) to the
+// listener method signature, the function itself is the receiver
+func functionListenerMethod(function any, options ...ListenerOption) eventListenerMethod {
+	functionValue := reflect.ValueOf(function)
+	lang.Assert(functionValue.Kind() == reflect.Func && !functionValue.IsNil(), "Event listener function expected, got %T", function)
+	functionType := functionValue.Type()
+	lang.Assert(functionType.NumIn() == 1 || functionType.NumIn() == 2 && functionType.In(0) == contextType,
+		"Event listener function must have one event argument, optionally preceded by context.Context, got %s", functionType)
+
+	in := []reflect.Type{anyType}
+	for i := 0; i < functionType.NumIn(); i++ {
+		in = append(in, functionType.In(i))
+	}
+	out := make([]reflect.Type, 0, functionType.NumOut())
+	for i := 0; i < functionType.NumOut(); i++ {
+		out = append(out, functionType.Out(i))
+	}
+	method := reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		return functionValue.Call(args[1:])
+	})
+	return newEventListenerMethod(method, fmt.Sprintf("function %s", functionType), options)
+}
+
+// Registered by ioc.OnEvent or ioc.Subscribe
+type functionListener struct {
+	method eventListenerMethod
+	close  func() // closes the subscription channel, nil for OnEvent functions
+}
+
+var anyType = lang.TypeOf[any]()
+var contextType = lang.TypeOf[context.Context]()
@@ -1,12 +1,19 @@
 package ioc
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"reflect"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
 	"github.com/go-errr/go/err"
 	"github.com/go-external-config/go/env"
 	"github.com/go-jang/go/lang"
@@ -18,9 +25,36 @@ type Profile string
 const (
 	Singleton Scope = iota
 	Prototype
+	Pooled
 )
 
+// Implements String
+func (this Scope) String() string {
+	switch this {
+	case Singleton:
+		return "singleton"
+	case Prototype:
+		return "prototype"
+	case Pooled:
+		return "pool"
+	default:
+		return fmt.Sprintf("Scope(%d)", int(this))
+	}
+}
+
+// lifetime ranks scopes, longer-lived scopes rank higher. Pool scoped beans
+// are injected as a shared pool handle.
+func (this Scope) lifetime() int {
+	switch this {
+	case Singleton, Pooled:
+		return 1
+	default:
+		return 0
+	}
+}
+
 var lifecycleType = lang.TypeOf[Lifecycle]()
+var lifecycleEType = lang.TypeOf[LifecycleE]()
 var phasedType = lang.TypeOf[Phased]()
 var applicationRunnerType = lang.TypeOf[ApplicationRunner]()
 var orderedType = lang.TypeOf[Ordered]()
@@ -40,10 +74,17 @@ type BeanDefinition interface {
 	getOrder() *int
 	getProfiles() []string
 	instantiate() any
+	newInstance() any
 	getInstance() any
+	getInitializedInstance() any
 	preDestroyEligible() bool
 	preDestroy()
+	destroy(instance any)
+	replace(instance any) any
 	getMutex() *sync.Mutex
+	getScopedProxy() any
+	getPool() any
+	newPool()
 	getEventListenerMethods(eventType reflect.Type) []eventListenerMethod
 	String() string
 }
@@ -58,10 +99,22 @@ type BeanDefinitionImpl[T any] struct {
 	phase                *int
 	order                *int
 	profiles             []string
-	factoryMethod        func() T
-	postConstructMethod  func(T)
-	preDestroyMethod     func(T)
-	instance             any
+	factoryMethod        func(context.Context) (T, error)
+	createTimeout        *time.Duration
+	retryAttempts        *int
+	retryBackoff         time.Duration
+	injectMethods        []injectMethod
+	postConstructMethod  func(T) error
+	preDestroyMethod     func(T) error
+	scopedProxyFactory   func(Provider[T]) T
+	scopedProxy          any
+	scopedProxyOnce      sync.Once
+	poolMin              int
+	poolMax              *int
+	validateMethod       func(T) error
+	pool                 atomic.Pointer[Pool[T]]
+	instance             atomic.Pointer[any] // exposed early to resolve circular references
+	initialized          atomic.Pointer[any]
 	mutex                sync.Mutex
 	eventListenerMethods []eventListenerMethod
 }
@@ -79,6 +132,8 @@ func (this *BeanDefinitionImpl[T]) Scope(scope string) *BeanDefinitionImpl[T] {
 		this.scope = Singleton
 	case "prototype":
 		this.scope = Prototype
+	case "pool":
+		this.scope = Pooled
 	default:
 		panic(err.NewIllegalArgumentException(fmt.Sprintf("%s scope not supported", scope)))
 	}
@@ -145,36 +200,140 @@ func (this *BeanDefinitionImpl[T]) Profile(profileExpr ...string) *BeanDefinitio
 	return this
 }
 
-func (this *BeanDefinitionImpl[T]) EventListener(method any) *BeanDefinitionImpl[T] {
+// Register the bean method receiving events assignable to its argument type.
+// Events returned by the method, a single event or a slice of events, are
+// published in order. A returned error fails the listener.
+//
+//	EventListener((*Service).OnUserCreated, ioc.Async())
+//
+//	func (this *Service) OnOrderPlaced(event *OrderPlaced) (*PaymentRequested, error)
+func (this *BeanDefinitionImpl[T]) EventListener(method any, options ...ListenerOption) *BeanDefinitionImpl[T] {
 	methodValue := reflect.ValueOf(method)
-	methodType := methodValue.Type()
+	lang.Assert(methodValue.Kind() == reflect.Func, "EventListener must be a method reference")
+	listener := newEventListenerMethod(methodValue, "EventListener method", options)
 
-	lang.Assert(methodType.Kind() == reflect.Func, "EventListener must be a method reference")
-	lang.Assert(methodType.NumIn() == 2, "EventListener method must have receiver and one event argument")
-	lang.Assert(methodType.NumOut() == 0, "EventListener method must not return values")
+	receiverType := methodValue.Type().In(0)
+	lang.Assert(this.t.AssignableTo(receiverType), "EventListener receiver %s does not match bean type %s", receiverType, this.t)
 
-	receiverType := methodType.In(0)
-	eventType := methodType.In(1)
+	this.eventListenerMethods = append(this.eventListenerMethods, listener)
+	return this
+}
 
-	lang.Assert(this.t.AssignableTo(receiverType), "EventListener receiver %s does not match bean type %s", receiverType, this.t)
+// Register the bean method receiving events on the container-managed executor, see Async
+func (this *BeanDefinitionImpl[T]) AsyncEventListener(method any, options ...ListenerOption) *BeanDefinitionImpl[T] {
+	return this.EventListener(method, append(options, Async())...)
+}
 
-	this.eventListenerMethods = append(this.eventListenerMethods, eventListenerMethod{
-		eventType: eventType,
+// Method injection for types exposing setters instead of fields. The first
+// argument is the bean, the remaining arguments are resolved by the container
+// and the function is invoked after field injection and before PostConstruct.
+//
+//	Inject(func(svc *Svc, dep *Dep, all []Handler) { svc.SetDep(dep); svc.SetHandlers(all) })
+//
+// Optional qualifiers follow the inject tag format and apply to the arguments
+// after the receiver in order:
+//
+//	Inject((*Svc).SetCache, "localCache,optional")
+func (this *BeanDefinitionImpl[T]) Inject(method any, qualifiers ...string) *BeanDefinitionImpl[T] {
+	methodValue := reflect.ValueOf(method)
+	lang.Assert(methodValue.Kind() == reflect.Func, "Inject must be a method reference or function")
+	methodType := methodValue.Type()
+
+	lang.Assert(methodType.NumIn() >= 1, "Inject method must have receiver argument")
+	lang.Assert(!methodType.IsVariadic(), "Inject method must not be variadic")
+	lang.Assert(methodType.NumOut() == 0, "Inject method must not return values")
+	lang.Assert(this.t.AssignableTo(methodType.In(0)), "Inject receiver %s does not match bean type %s", methodType.In(0), this.t)
+	lang.Assert(len(qualifiers) <= methodType.NumIn()-1, "Inject method %s has %d arguments to inject, %d qualifiers provided", methodType, methodType.NumIn()-1, len(qualifiers))
+
+	arguments := make([]InjectQualifier[any], 0, methodType.NumIn()-1)
+	for i := 1; i < methodType.NumIn(); i++ {
+		var name string
+		var optional, group bool
+		if i-1 < len(qualifiers) {
+			name, optional, group = parseInjectQualifier(qualifiers[i-1], fmt.Sprintf("argument %d of %s", i, methodType))
+			lang.Assert(!group, "Unsupported inject option '%s' used for argument %d of %s", Group, i, methodType)
+		}
+		arguments = append(arguments, InjectQualifier[any]{
+			t:        methodType.In(i),
+			name:     name,
+			optional: optional,
+		})
+	}
+
+	this.injectMethods = append(this.injectMethods, injectMethod{
 		method:    methodValue,
+		arguments: arguments,
 	})
+	return this
+}
 
+// Retry failed factory and PostConstruct invocations, for example while
+// sidecars are not ready yet. Attempts include the first invocation, the
+// backoff doubles after every failed attempt.
+// Default: ioc.retry.attempts and ioc.retry.backoff properties, no retry if not set
+func (this *BeanDefinitionImpl[T]) Retry(attempts int, backoff time.Duration) *BeanDefinitionImpl[T] {
+	lang.Assert(this.retryAttempts == nil, "Retry is defined twice")
+	lang.Assert(attempts >= 1, "Retry attempts must be positive")
+	lang.Assert(backoff >= 0, "Retry backoff must not be negative")
+	this.retryAttempts = &attempts
+	this.retryBackoff = backoff
 	return this
 }
 
 // Set the factory method reference or anonymous function with actual implementation
 func (this *BeanDefinitionImpl[T]) Factory(f func() T) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "Bean factory method must be provided")
+	return this.FactoryE(func() (T, error) {
+		return f(), nil
+	})
+}
+
+// Set the factory method returning an error, like func NewPool() (*Pool, error)
+func (this *BeanDefinitionImpl[T]) FactoryE(f func() (T, error)) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "Bean factory method must be provided")
+	return this.FactoryContext(func(context.Context) (T, error) {
+		return f()
+	})
+}
+
+// Set the factory method receiving a context derived from ioc.Context().
+// The context is cancelled when the creation timeout elapses or the
+// application context is closed. Factories must return once it is done,
+// with Retry every timed out attempt otherwise leaves a running factory.
+//
+//	FactoryContext(func(ctx context.Context) (*pgxpool.Pool, error) {
+//		return pgxpool.New(ctx, url)
+//	})
+func (this *BeanDefinitionImpl[T]) FactoryContext(f func(ctx context.Context) (T, error)) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "Bean factory method must be provided")
 	lang.Assert(this.factoryMethod == nil, "Factory is defined twice")
 	this.factoryMethod = f
 	return this
 }
 
+// Abort bean creation if the factory does not return in time. The factory
+// keeps running on its goroutine, so it must honor the FactoryContext ctx;
+// an instance returned after the timeout is destroyed.
+// Default: ioc.create-timeout property, no timeout if not set
+func (this *BeanDefinitionImpl[T]) CreateTimeout(timeout time.Duration) *BeanDefinitionImpl[T] {
+	lang.Assert(this.createTimeout == nil, "CreateTimeout is defined twice")
+	lang.Assert(timeout > 0, "CreateTimeout must be positive")
+	this.createTimeout = &timeout
+	return this
+}
+
 // It is safe to use injected beans at this point
 func (this *BeanDefinitionImpl[T]) PostConstruct(f func(T)) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "PostConstruct method must be provided")
+	return this.PostConstructE(func(bean T) error {
+		f(bean)
+		return nil
+	})
+}
+
+// PostConstruct returning an error. The error fails bean creation.
+func (this *BeanDefinitionImpl[T]) PostConstructE(f func(T) error) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "PostConstruct method must be provided")
 	lang.Assert(this.postConstructMethod == nil, "PostConstruct is defined twice")
 	this.postConstructMethod = f
 	return this
@@ -182,15 +341,70 @@ func (this *BeanDefinitionImpl[T]) PostConstruct(f func(T)) *BeanDefinitionImpl[
 
 // Clean-up resources before shutdown. Not called on prototype beans.
 func (this *BeanDefinitionImpl[T]) PreDestroy(f func(T)) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "PreDestroy method must be provided")
+	return this.PreDestroyE(func(bean T) error {
+		f(bean)
+		return nil
+	})
+}
+
+// PreDestroy returning an error. The error is logged and shutdown continues.
+func (this *BeanDefinitionImpl[T]) PreDestroyE(f func(T) error) *BeanDefinitionImpl[T] {
+	lang.Assert(f != nil, "PreDestroy method must be provided")
 	lang.Assert(this.preDestroyMethod == nil, "PreDestroy is defined twice")
 	lang.Assert(this.scope != Prototype, "PreDestroy cannot be used for Prototype scope beans")
 	this.preDestroyMethod = f
 	return this
 }
 
+// Proxy injected into longer-lived beans instead of the scoped instance. The
+// proxy implements the bean interface by delegating every call to the
+// instance returned by target, which is resolved in the bean scope on each call.
+//
+//	ScopedProxy(func(target ioc.Provider[Clock]) Clock { return clockProxy{target} })
+//
+//	func (this clockProxy) Now() time.Time { return this.target().Now() }
+func (this *BeanDefinitionImpl[T]) ScopedProxy(proxy func(target Provider[T]) T) *BeanDefinitionImpl[T] {
+	lang.Assert(this.t.Kind() == reflect.Interface, "ScopedProxy may be applied only to interface type bean, got %s", this.t)
+	lang.Assert(this.scopedProxyFactory == nil, "ScopedProxy is defined twice")
+	this.scopedProxyFactory = proxy
+	return this
+}
+
+// Size of the pool scope bean. Min instances are created with the pool,
+// Borrow blocks while max instances are borrowed.
+// Default: 0 and runtime.NumCPU()
+func (this *BeanDefinitionImpl[T]) PoolSize(min, max int) *BeanDefinitionImpl[T] {
+	lang.Assert(this.poolMax == nil, "PoolSize is defined twice")
+	lang.Assert(min >= 0 && max >= 1 && min <= max, "PoolSize requires 0 <= min <= max and max >= 1, got %d, %d", min, max)
+	this.poolMin = min
+	this.poolMax = &max
+	return this
+}
+
+// Validate pool scope bean instance on borrow. Invalid instances are destroyed and replaced.
+func (this *BeanDefinitionImpl[T]) Validate(f func(T) error) *BeanDefinitionImpl[T] {
+	lang.Assert(this.validateMethod == nil, "Validate is defined twice")
+	this.validateMethod = f
+	return this
+}
+
 // Register the bean within the context
 func (this *BeanDefinitionImpl[T]) Register() {
 	lang.Assert(this.factoryMethod != nil, "Bean factory method must be provided")
+	if this.scope != Pooled {
+		lang.Assert(this.poolMax == nil, "PoolSize may be applied only to pool scope bean")
+		lang.Assert(this.validateMethod == nil, "Validate may be applied only to pool scope bean")
+	} else {
+		lang.Assert(len(this.eventListenerMethods) == 0, "EventListener cannot be used for pool scope beans")
+		lang.Assert(this.scopedProxyFactory == nil, "ScopedProxy cannot be used for pool scope beans")
+		// pool handle is resolved instead of instances, instances are not started or run
+		lang.Assert(!this.isLifecycleBean(), "Pool scope bean %v must not implement Lifecycle", this.t)
+		lang.Assert(!this.isApplicationRunner(), "Pool scope bean %v must not implement ApplicationRunner", this.t)
+	}
+	if listener, ok := applicationListenerMethod(this.t); ok && this.scope != Pooled {
+		this.eventListenerMethods = append(this.eventListenerMethods, listener)
+	}
 	applicationContextInstance().register(this)
 }
 
@@ -216,7 +430,7 @@ func (this *BeanDefinitionImpl[T]) isLazy() bool {
 }
 
 func (this *BeanDefinitionImpl[T]) isLifecycleBean() bool {
-	return this.getType().Implements(lifecycleType)
+	return this.getType().Implements(lifecycleType) || this.getType().Implements(lifecycleEType)
 }
 
 func (this *BeanDefinitionImpl[T]) isPhased() bool {
@@ -247,9 +461,39 @@ func (this *BeanDefinitionImpl[T]) getProfiles() []string {
 	return this.profiles
 }
 
+// instantiate creates the singleton instance, exposed early to resolve
+// circular references and published once initialized
 func (this *BeanDefinitionImpl[T]) instantiate() any {
-	instance := this.factoryMethod()
-	this.instance = instance
+	defer err.Catch(func(e any) {
+		// failed bean must not be exposed as created singleton
+		this.instance.Store(nil)
+		panic(e)
+	})
+	var exposed any
+	instance := this.build(func(instance T) {
+		exposed = instance
+		this.instance.Store(&exposed)
+	})
+	this.initialized.Store(&exposed)
+	return instance
+}
+
+// newInstance creates a prototype or pooled instance, singleton state is not touched
+func (this *BeanDefinitionImpl[T]) newInstance() any {
+	return this.build(func(T) {})
+}
+
+// build creates and initializes an instance, expose is called before initialization
+func (this *BeanDefinitionImpl[T]) build(expose func(instance T)) T {
+	var instance T
+	this.retry("Factory", func() {
+		created, e := this.create()
+		if e != nil {
+			panic(err.NewRuntimeExceptionFrom("Bean factory failed", e))
+		}
+		instance = created
+	})
+	expose(instance)
 	var obj any = instance
 	if bean, ok := obj.(BeanNameAware); ok && len(this.names) > 0 {
 		bean.SetBeanName(this.names[0])
@@ -263,10 +507,17 @@ func (this *BeanDefinitionImpl[T]) instantiate() any {
 	value := reflect.ValueOf(instance)
 	if value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Kind() == reflect.Struct {
 		env.BindPropertiesAny(instance)
-		injectBeansAny(instance)
+		injectBeansAny(instance, this)
+	}
+	for _, method := range this.injectMethods {
+		method.invoke(this, instance)
 	}
 	if this.postConstructMethod != nil {
-		this.postConstructMethod(instance)
+		this.retry("PostConstruct", func() {
+			if e := this.postConstructMethod(instance); e != nil {
+				panic(err.NewRuntimeExceptionFrom("PostConstruct failed", e))
+			}
+		})
 	}
 	if bean, ok := obj.(InitializingBean); ok {
 		bean.AfterPropertiesSet()
@@ -274,27 +525,164 @@ func (this *BeanDefinitionImpl[T]) instantiate() any {
 	return instance
 }
 
+// retry invokes the callback until it succeeds or retry attempts are exhausted.
+// The failure of the last attempt is propagated unchanged.
+func (this *BeanDefinitionImpl[T]) retry(callback string, f func()) {
+	attempts := env.Value[int]("${" + retryAttemptsProperty + ":1}")
+	backoff := durationProperty(retryBackoffProperty)
+	if this.retryAttempts != nil {
+		attempts, backoff = *this.retryAttempts, this.retryBackoff
+	}
+	for attempt := 1; ; attempt++ {
+		failure := catchPanic(f)
+		if failure == nil {
+			return
+		}
+		if attempt >= attempts {
+			panic(failure)
+		}
+		slog.Warn(fmt.Sprintf("%s attempt %d of %d failed for bean %v, retrying in %v. %s", callback, attempt, attempts, this, backoff, causeChain(failure)))
+		select {
+		case <-time.After(backoff):
+		case <-applicationContextInstance().context.Done():
+			panic(failure)
+		}
+		backoff *= 2
+	}
+}
+
+// causeChain formats messages of the error and its causes on one line
+func causeChain(e any) string {
+	cause, ok := e.(error)
+	if !ok {
+		return fmt.Sprint(e)
+	}
+	messages := make([]string, 0)
+	for ; cause != nil; cause = errors.Unwrap(cause) {
+		messages = append(messages, cause.Error())
+	}
+	return strings.Join(messages, ": ")
+}
+
+func catchPanic(f func()) (failure any) {
+	defer err.Catch(func(e any) {
+		failure = e
+	})
+	f()
+	return nil
+}
+
+// Outcome of the factory method invoked on a separate goroutine
+type createResult[T any] struct {
+	instance T
+	e        error
+	panic    any
+}
+
+// create invokes the factory method, waiting no longer than the creation timeout
+func (this *BeanDefinitionImpl[T]) create() (T, error) {
+	timeout := durationProperty(createTimeoutProperty)
+	if this.createTimeout != nil {
+		timeout = *this.createTimeout
+	}
+	if timeout <= 0 {
+		return this.factoryMethod(applicationContextInstance().context)
+	}
+
+	ctx, cancel := context.WithTimeout(applicationContextInstance().context, timeout)
+	defer cancel()
+
+	done := make(chan createResult[T], 1)
+	go func() {
+		defer err.Recover(func(e any) {
+			done <- createResult[T]{panic: e}
+		})
+		instance, e := this.factoryMethod(ctx)
+		done <- createResult[T]{instance: instance, e: e}
+	}()
+
+	select {
+	case r := <-done:
+		if r.panic != nil {
+			panic(r.panic)
+		}
+		return r.instance, r.e
+	case <-ctx.Done():
+		go this.destroyLate(done)
+		var zero T
+		if ctx.Err() == context.DeadlineExceeded {
+			return zero, err.NewRuntimeExceptionFrom(fmt.Sprintf("Bean creation timed out after %v: %v", timeout, this), ctx.Err())
+		}
+		return zero, err.NewRuntimeExceptionFrom(fmt.Sprintf("Bean creation cancelled: %v", this), ctx.Err())
+	}
+}
+
+// destroyLate waits for the abandoned factory goroutine and destroys the
+// instance returned after the creation timeout, so created clients do not leak
+func (this *BeanDefinitionImpl[T]) destroyLate(done <-chan createResult[T]) {
+	r := <-done
+	if r.panic != nil || r.e != nil || isNil(reflect.ValueOf(&r.instance).Elem()) {
+		return
+	}
+	slog.Warn(fmt.Sprintf("ioc.ApplicationContext: destroying %v created after the creation timeout", this))
+	this.destroy(r.instance)
+}
+
 func (this *BeanDefinitionImpl[T]) preDestroyEligible() bool {
-	var obj any = this.instance
+	if this.scope == Pooled {
+		return this.pool.Load() != nil
+	}
+	obj := this.getInstance()
 	_, isDisposable := obj.(DisposableBean)
 	return this.scope == Singleton && (this.preDestroyMethod != nil || isDisposable)
 }
 
 func (this *BeanDefinitionImpl[T]) preDestroy() {
+	if this.scope == Pooled {
+		this.pool.Load().close()
+		return
+	}
+	this.destroy(this.getInstance())
+}
+
+func (this *BeanDefinitionImpl[T]) destroy(instance any) {
 	defer err.Recover(func(e any) {
 		slog.Error(fmt.Sprintf("Could not destroy bean %v. %s", this, err.PrintStackTrace(e)))
 	})
 	if this.preDestroyMethod != nil {
-		this.preDestroyMethod(this.instance.(T))
+		if e := this.preDestroyMethod(instance.(T)); e != nil {
+			panic(err.NewRuntimeExceptionFrom("PreDestroy failed", e))
+		}
 	}
-	var obj any = this.instance
-	if bean, ok := obj.(DisposableBean); ok {
+	if bean, ok := instance.(DisposableBean); ok {
 		bean.Destroy()
 	}
 }
 
+// replace swaps the singleton instance and returns the previous one
+func (this *BeanDefinitionImpl[T]) replace(instance any) any {
+	replacement, ok := instance.(T)
+	lang.Assert(ok, "Cannot replace bean %v with %T", this, instance)
+	var stored any = replacement
+	this.initialized.Store(&stored)
+	return *this.instance.Swap(&stored)
+}
+
 func (this *BeanDefinitionImpl[T]) getInstance() any {
-	return this.instance
+	instance := this.instance.Load()
+	if instance == nil {
+		return nil
+	}
+	return *instance
+}
+
+// getInitializedInstance returns the singleton once instantiate completed
+func (this *BeanDefinitionImpl[T]) getInitializedInstance() any {
+	instance := this.initialized.Load()
+	if instance == nil {
+		return nil
+	}
+	return *instance
 }
 
 func (this *BeanDefinitionImpl[T]) getMutex() *sync.Mutex {
@@ -311,10 +699,41 @@ func (this *BeanDefinitionImpl[T]) getEventListenerMethods(eventType reflect.Typ
 	return methods
 }
 
+// getScopedProxy returns the proxy created once for the bean definition
+func (this *BeanDefinitionImpl[T]) getScopedProxy() any {
+	if this.scopedProxyFactory == nil {
+		return nil
+	}
+	this.scopedProxyOnce.Do(func() {
+		this.scopedProxy = this.scopedProxyFactory(func() T {
+			instance, _ := applicationContextInstance().beanInstance(this).(T)
+			return instance
+		})
+	})
+	return this.scopedProxy
+}
+
+func (this *BeanDefinitionImpl[T]) getPool() any {
+	pool := this.pool.Load()
+	if pool == nil {
+		return nil
+	}
+	return pool
+}
+
+// Must be called holding the bean mutex
+func (this *BeanDefinitionImpl[T]) newPool() {
+	max := runtime.NumCPU()
+	if this.poolMax != nil {
+		max = *this.poolMax
+	}
+	this.pool.Store(newPool(this, this.poolMin, max))
+}
+
 // Implements String
 func (this *BeanDefinitionImpl[T]) String() string {
 	return fmt.Sprintf("%s [%s%s%s%s%s%s]", this.t,
-		lang.If(this.scope == Singleton, "singleton", "prototype"),
+		this.scope,
 		lang.If(len(this.names) > 0, " "+strings.Join(this.names, ", "), ""),
 		lang.If(this.primary, " primary", ""),
 		lang.If(this.lazy, " lazy", ""),
@@ -322,11 +741,121 @@ func (this *BeanDefinitionImpl[T]) String() string {
 		lang.If(this.isApplicationRunner(), " ApplicationRunner", ""))
 }
 
+func newEventListenerMethod(method reflect.Value, description string, options []ListenerOption) eventListenerMethod {
+	methodType := method.Type()
+	withContext := methodType.NumIn() == 3 && methodType.In(1) == contextType
+	lang.Assert(methodType.NumIn() == 2 || withContext, "%s must have receiver and one event argument, optionally preceded by context.Context", description)
+	lang.Assert(methodType.NumOut() <= 1 || methodType.NumOut() == 2 && methodType.Out(1) == errorType,
+		"%s must return nothing, an event or an error, or an event and an error", description)
+
+	listener := eventListenerMethod{
+		eventType:   methodType.In(methodType.NumIn() - 1),
+		method:      method,
+		withContext: withContext,
+	}
+	for _, option := range options {
+		option(&listener)
+	}
+	return listener
+}
+
 type eventListenerMethod struct {
-	eventType reflect.Type
+	eventType       reflect.Type
+	method          reflect.Value
+	withContext     bool // accepts (ctx context.Context, event E)
+	async           bool
+	instantiate     bool
+	sticky          bool
+	order           *int
+	condition       *vm.Program
+	conditionSource string
+}
+
+// invoke returns events produced by the listener
+func (this eventListenerMethod) invoke(ctx context.Context, bean any, event reflect.Value) []any {
+	if !this.accepts(event) {
+		return nil
+	}
+	args := []reflect.Value{reflect.ValueOf(bean), event}
+	if this.withContext {
+		args = []reflect.Value{reflect.ValueOf(bean), reflect.ValueOf(ctx), event}
+	}
+	results := this.method.Call(args)
+	if n := len(results); n > 0 && results[n-1].Type() == errorType {
+		if !results[n-1].IsNil() {
+			panic(err.NewRuntimeExceptionFrom("Event listener failed", results[n-1].Interface()))
+		}
+		results = results[:n-1]
+	}
+	var produced []any
+	for _, result := range results {
+		if isNil(result) {
+			continue
+		}
+		value := reflect.ValueOf(result.Interface())
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			produced = append(produced, value.Interface())
+			continue
+		}
+		for i := 0; i < value.Len(); i++ {
+			if !isNil(value.Index(i)) {
+				produced = append(produced, value.Index(i).Interface())
+			}
+		}
+	}
+	return produced
+}
+
+// orderOr returns ListenerOrder or the default
+func (this eventListenerMethod) orderOr(order int) int {
+	if this.order != nil {
+		return *this.order
+	}
+	return order
+}
+
+func isNil(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
+
+func (this eventListenerMethod) accepts(event reflect.Value) bool {
+	if this.condition == nil {
+		return true
+	}
+	output, e := expr.Run(this.condition, map[string]any{"event": event.Interface()})
+	if e != nil {
+		panic(err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot evaluate listener condition '%s'", this.conditionSource), e))
+	}
+	return output.(bool)
+}
+
+type injectMethod struct {
 	method    reflect.Value
+	arguments []InjectQualifier[any]
 }
 
-func (this eventListenerMethod) invoke(bean any, event reflect.Value) {
-	this.method.Call([]reflect.Value{reflect.ValueOf(bean), event})
+func (this injectMethod) invoke(owner BeanDefinition, bean any) {
+	args := make([]reflect.Value, 0, len(this.arguments)+1)
+	args = append(args, reflect.ValueOf(bean))
+	for i, argument := range this.arguments {
+		argument.owner = owner
+		args = append(args, this.resolveArgument(i+1, argument))
+	}
+	this.method.Call(args)
+}
+
+func (this injectMethod) resolveArgument(index int, argument InjectQualifier[any]) reflect.Value {
+	defer err.Catch(func(e any) {
+		panic(err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot inject dependency into argument %d of %s", index, this.method.Type()), e))
+	})
+	bean := argument.resolve()()
+	if bean == nil {
+		return reflect.Zero(argument.t)
+	}
+	return reflect.ValueOf(bean)
 }
@@ -0,0 +1,43 @@
+package ioc
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Read-only snapshot of a registered bean definition
+type BeanDescriptor struct {
+	Type         reflect.Type
+	Names        []string
+	Scope        Scope
+	Profiles     []string
+	Primary      bool
+	Lazy         bool
+	DependsOn    []string
+	Order        *int
+	Phase        *int
+	Instantiated bool // singleton instance created
+}
+
+func newBeanDescriptor(bean BeanDefinition) BeanDescriptor {
+	return BeanDescriptor{
+		Type:         bean.getType(),
+		Names:        slices.Clone(bean.getNames()),
+		Scope:        bean.getScope(),
+		Profiles:     slices.Clone(bean.getProfiles()),
+		Primary:      bean.isPrimary(),
+		Lazy:         bean.isLazy(),
+		DependsOn:    slices.Clone(bean.getDependsOn()),
+		Order:        clonePtr(bean.getOrder()),
+		Phase:        clonePtr(bean.getPhase()),
+		Instantiated: bean.getScope() == Singleton && bean.getInstance() != nil,
+	}
+}
+
+func clonePtr[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	clone := *value
+	return &clone
+}
@@ -0,0 +1,81 @@
+package ioc
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/go-errr/go/err"
+	"github.com/go-external-config/go/env"
+	"github.com/go-jang/go/lang"
+)
+
+// Snapshot of bean definitions accepted for the active profiles.
+//
+// The registry is never modified once published. Changes are applied to a
+// copy which replaces the published snapshot, so bean resolution reads it
+// from any goroutine without locking.
+type beanRegistry struct {
+	profiles   []string
+	evaluated  int
+	registered []BeanDefinition
+	beans      map[reflect.Type][]BeanDefinition
+	named      map[string]BeanDefinition
+	outcomes   []ConditionOutcome
+	failure    any // first registration failure, rethrown on resolution
+}
+
+func newBeanRegistry(profiles []string) *beanRegistry {
+	return &beanRegistry{
+		profiles:   profiles,
+		registered: make([]BeanDefinition, 0),
+		beans:      make(map[reflect.Type][]BeanDefinition),
+		named:      make(map[string]BeanDefinition),
+	}
+}
+
+func (this *beanRegistry) copy(profiles []string) *beanRegistry {
+	return &beanRegistry{
+		profiles:   profiles,
+		evaluated:  this.evaluated,
+		registered: slices.Clip(this.registered),
+		beans:      maps.Clone(this.beans),
+		named:      maps.Clone(this.named),
+		outcomes:   slices.Clip(this.outcomes),
+		failure:    this.failure,
+	}
+}
+
+// isCurrent reports whether the snapshot evaluated all definitions for the active profiles
+func (this *beanRegistry) isCurrent(definitions int) bool {
+	return this.evaluated == definitions && slices.Equal(this.profiles, env.ActiveProfiles())
+}
+
+// accept evaluates the bean definition conditions and adds matching definition.
+// Slices are clipped on copy, so appending never writes into a published snapshot.
+// Failures are kept, so the registry is never left partially evaluated.
+func (this *beanRegistry) accept(bean BeanDefinition) {
+	defer err.Catch(func(e any) {
+		if this.failure == nil {
+			this.failure = err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot register bean %v", bean), e)
+		}
+	})
+	outcome := evaluateCondition(bean)
+	this.outcomes = append(this.outcomes, outcome)
+	if !outcome.Matched {
+		slog.Debug(fmt.Sprintf("ioc.ApplicationContext: skipped %s, %s", bean, outcome.Reason))
+		return
+	}
+	for _, name := range bean.getNames() {
+		_, ok := this.named[name]
+		lang.Assert(!ok, "Bean with name '%s' already registered", name)
+	}
+	for _, name := range bean.getNames() {
+		this.named[name] = bean
+	}
+	this.beans[bean.getType()] = append(slices.Clip(this.beans[bean.getType()]), bean)
+	this.registered = append(this.registered, bean)
+	slog.Debug(fmt.Sprintf("ioc.ApplicationContext: registered %s", bean))
+}
@@ -0,0 +1,11 @@
+package ioc
+
+type BeanReplacedEvent struct {
+	Name        string
+	OldInstance any
+	NewInstance any
+}
+
+func NewBeanReplacedEvent(name string, oldInstance, newInstance any) *BeanReplacedEvent {
+	return &BeanReplacedEvent{Name: name, OldInstance: oldInstance, NewInstance: newInstance}
+}
@@ -0,0 +1,75 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-external-config/go/env"
+	"github.com/go-jang/go/lang"
+)
+
+// Records why each bean definition was accepted or skipped for active profiles
+type ConditionEvaluationReport struct {
+	ActiveProfiles []string
+	Outcomes       []ConditionOutcome
+}
+
+// Outcome of the condition evaluation for one bean definition
+type ConditionOutcome struct {
+	Definition string
+	Type       reflect.Type
+	Names      []string
+	Profiles   []string
+	Matched    bool
+	Reason     string
+}
+
+func evaluateCondition(bean BeanDefinition) ConditionOutcome {
+	outcome := ConditionOutcome{
+		Definition: bean.String(),
+		Type:       bean.getType(),
+		Names:      bean.getNames(),
+		Profiles:   bean.getProfiles(),
+	}
+	if len(bean.getProfiles()) == 0 {
+		outcome.Matched = true
+		outcome.Reason = "no profile condition"
+		return outcome
+	}
+	for _, profile := range bean.getProfiles() {
+		if env.MatchesProfiles(profile) {
+			outcome.Matched = true
+			outcome.Reason = fmt.Sprintf("profile '%s' matched", profile)
+			return outcome
+		}
+	}
+	outcome.Reason = fmt.Sprintf("profile '%s' did not match active profiles %v", strings.Join(bean.getProfiles(), "', '"), env.ActiveProfiles())
+	return outcome
+}
+
+// Skipped returns outcomes of bean definitions excluded from the context
+func (this ConditionEvaluationReport) Skipped() []ConditionOutcome {
+	skipped := make([]ConditionOutcome, 0)
+	for _, outcome := range this.Outcomes {
+		if !outcome.Matched {
+			skipped = append(skipped, outcome)
+		}
+	}
+	return skipped
+}
+
+// Implements String
+func (this ConditionEvaluationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Condition evaluation report, active profiles %v", this.ActiveProfiles)
+	for _, outcome := range this.Outcomes {
+		fmt.Fprintf(&b, "\n\t%s", outcome)
+	}
+	return b.String()
+}
+
+// Implements String
+func (this ConditionOutcome) String() string {
+	return fmt.Sprintf("%s %s: %s", lang.If(this.Matched, "accepted", "skipped "), this.Definition, this.Reason)
+}
@@ -0,0 +1,65 @@
+package ioc
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/go-errr/go/err"
+	"github.com/go-jang/go/util/concurrent"
+)
+
+// Bean name of the ErrorHandler overriding the default
+const EventErrorHandlerBeanName = "eventErrorHandler"
+
+// Decides how event listener failures are handled. Register a bean named
+// EventErrorHandlerBeanName to override the default, which rethrows failures of
+// PublishEvent to the publisher. Failures of built-in events and async listeners
+// are logged.
+type ErrorHandler interface {
+	HandleError(e error)
+}
+
+// Logs the failure, remaining listeners are notified
+type LoggingErrorHandler struct{}
+
+// Implements ErrorHandler
+func (this LoggingErrorHandler) HandleError(e error) {
+	slog.Error(err.PrintStackTrace(e))
+}
+
+// Rethrows the failure to the publisher, remaining listeners are not notified
+type PropagatingErrorHandler struct{}
+
+// Implements ErrorHandler
+func (this PropagatingErrorHandler) HandleError(e error) {
+	panic(e)
+}
+
+// Collects failures into an aggregated error, remaining listeners are notified
+//
+//	ioc.Bean[*ioc.CollectingErrorHandler]().Factory(ioc.NewCollectingErrorHandler).Register()
+type CollectingErrorHandler struct {
+	errors []error
+	mutex  sync.Mutex
+}
+
+func NewCollectingErrorHandler() *CollectingErrorHandler {
+	return &CollectingErrorHandler{}
+}
+
+// Implements ErrorHandler
+func (this *CollectingErrorHandler) HandleError(e error) {
+	concurrent.Synchronized(&this.mutex, func() {
+		this.errors = append(this.errors, e)
+	})
+}
+
+// Err returns collected failures joined, nil if none
+func (this *CollectingErrorHandler) Err() error {
+	var joined error
+	concurrent.Synchronized(&this.mutex, func() {
+		joined = errors.Join(this.errors...)
+	})
+	return joined
+}
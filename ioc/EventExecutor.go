@@ -0,0 +1,145 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errr/go/err"
+	"github.com/go-jang/go/util/concurrent"
+)
+
+// Overflow policy of the async event queue
+const (
+	blockPolicy      = "block"
+	callerRunsPolicy = "caller-runs"
+	discardPolicy    = "discard"
+)
+
+// eventExecutor dispatches async events to a fixed number of workers through
+// a bounded queue. Events accepted before drain are delivered unless the drain
+// timeout elapses.
+type eventExecutor struct {
+	tasks    chan func()
+	done     chan struct{}
+	policy   string
+	inFlight sync.WaitGroup
+	pending  map[uint64]string // descriptions of accepted tasks by id
+	nextID   uint64
+	closed   bool
+	mutex    sync.Mutex
+}
+
+func newEventExecutor(workers, capacity int, policy string) *eventExecutor {
+	if policy != blockPolicy && policy != callerRunsPolicy && policy != discardPolicy {
+		panic(err.NewIllegalArgumentException(fmt.Sprintf("Unsupported %s '%s', expected %s, %s or %s",
+			asyncEventsPolicyProperty, policy, blockPolicy, callerRunsPolicy, discardPolicy)))
+	}
+	this := &eventExecutor{
+		tasks:   make(chan func(), capacity),
+		done:    make(chan struct{}),
+		policy:  policy,
+		pending: make(map[uint64]string),
+	}
+	for range workers {
+		go func() {
+			for {
+				select {
+				case task := <-this.tasks:
+					task()
+				case <-this.done:
+					return
+				}
+			}
+		}()
+	}
+	return this
+}
+
+// execute queues the task according to the overflow policy, returns false
+// if the executor is drained and the task must be run by the caller. Blocked
+// publishers give up once the context is done.
+func (this *eventExecutor) execute(ctx context.Context, task func(), description string) bool {
+	var id uint64
+	var accepted bool
+	concurrent.Synchronized(&this.mutex, func() {
+		if !this.closed {
+			this.inFlight.Add(1)
+			this.nextID++
+			id = this.nextID
+			this.pending[id] = description
+			accepted = true
+		}
+	})
+	if !accepted {
+		return false
+	}
+	job := func() {
+		defer this.finish(id)
+		task()
+	}
+	if this.policy == blockPolicy {
+		select {
+		case this.tasks <- job:
+		case <-ctx.Done():
+			this.finish(id)
+			slog.Debug(fmt.Sprintf("ioc.ApplicationContext: skipping async event %s. %v", description, ctx.Err()))
+		}
+		return true
+	}
+	select {
+	case this.tasks <- job:
+	default:
+		this.finish(id)
+		if this.policy == callerRunsPolicy {
+			task()
+		} else {
+			slog.Warn(fmt.Sprintf("ioc.ApplicationContext: async event queue is full, discarding %s", description))
+		}
+	}
+	return true
+}
+
+// finish marks the accepted task delivered or dropped
+func (this *eventExecutor) finish(id uint64) {
+	concurrent.Synchronized(&this.mutex, func() {
+		delete(this.pending, id)
+	})
+	this.inFlight.Done()
+}
+
+// drain stops accepting tasks, waits for queued and running ones up to the
+// timeout and stops workers. Tasks still pending after the timeout are logged
+// and abandoned, a stuck listener must not block shutdown.
+func (this *eventExecutor) drain(timeout time.Duration) {
+	var draining bool
+	concurrent.Synchronized(&this.mutex, func() {
+		draining = !this.closed
+		this.closed = true
+	})
+	drained := make(chan struct{})
+	go func() {
+		this.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		var abandoned []string
+		concurrent.Synchronized(&this.mutex, func() {
+			for _, description := range this.pending {
+				abandoned = append(abandoned, description)
+			}
+		})
+		slices.Sort(abandoned)
+		slog.Warn(fmt.Sprintf("ioc.ApplicationContext: async events not delivered within %v, abandoning %d: %s",
+			timeout, len(abandoned), strings.Join(abandoned, ", ")))
+	}
+	if draining {
+		close(this.done)
+	}
+}
@@ -13,6 +13,9 @@ type InjectQualifier[T any] struct {
 	t         reflect.Type
 	name      string
 	optional  bool
+	owner     BeanDefinition
+	pooled    bool
+	ref       reflect.Type // requested *Ref[T]
 }
 
 func newInjectQualifier[T any]() *InjectQualifier[T] {
@@ -55,6 +58,13 @@ func (this *InjectQualifier[T]) resolve() func() T {
 	}
 }
 
+func (this *InjectQualifier[T]) resolveE() (instance T, e error) {
+	defer err.Catch(func(r any) {
+		e = err.NewRuntimeExceptionFrom("Cannot resolve bean.", r)
+	})
+	return this.doResolve(), nil
+}
+
 func (this *InjectQualifier[T]) doResolve() T {
 	var instance T
 	raw := applicationContextInstance().bean(&InjectQualifier[any]{
@@ -62,6 +72,7 @@ func (this *InjectQualifier[T]) doResolve() T {
 		t:         this.t,
 		name:      this.name,
 		optional:  this.optional,
+		owner:     this.owner,
 	})
 	if raw != nil {
 		val, ok := raw.(T)
@@ -1,7 +1,40 @@
 package ioc
 
+import "github.com/go-errr/go/err"
+
 // see Phased
 type Lifecycle interface {
 	Start()
 	Stop()
 }
+
+// Lifecycle variant reporting failures as errors.
+// Start error fails the context refresh, Stop error is logged.
+//
+// see Phased
+type LifecycleE interface {
+	Start() error
+	Stop() error
+}
+
+func startLifecycle(bean any) {
+	switch lifecycle := bean.(type) {
+	case Lifecycle:
+		lifecycle.Start()
+	case LifecycleE:
+		if e := lifecycle.Start(); e != nil {
+			panic(err.NewRuntimeExceptionFrom("Lifecycle start failed", e))
+		}
+	}
+}
+
+func stopLifecycle(bean any) {
+	switch lifecycle := bean.(type) {
+	case Lifecycle:
+		lifecycle.Stop()
+	case LifecycleE:
+		if e := lifecycle.Stop(); e != nil {
+			panic(err.NewRuntimeExceptionFrom("Lifecycle stop failed", e))
+		}
+	}
+}
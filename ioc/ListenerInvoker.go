@@ -0,0 +1,77 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/go-errr/go/err"
+)
+
+// Listener resolved for a published event, see ApplicationEventMulticaster
+type ListenerInvoker struct {
+	context      *ApplicationContext
+	ctx          context.Context
+	listener     eventListener
+	errorHandler ErrorHandler
+}
+
+// Async reports whether the listener is registered with the Async option
+func (this *ListenerInvoker) Async() bool {
+	return this.listener.method.async
+}
+
+// Context the event is published with
+func (this *ListenerInvoker) Context() context.Context {
+	return this.ctx
+}
+
+// Invoke notifies the listener on the caller goroutine and publishes events
+// returned by the listener. The listener failure is returned as error.
+func (this *ListenerInvoker) Invoke(event any) error {
+	produced, e := this.invoke(event)
+	if e != nil {
+		return e
+	}
+	for _, next := range produced {
+		this.context.publishEvent(this.ctx, next, this.errorHandler)
+	}
+	return nil
+}
+
+func (this *ListenerInvoker) invoke(event any) (produced []any, e error) {
+	defer err.Catch(func(r any) {
+		e = err.NewRuntimeExceptionFrom(fmt.Sprintf("Notify failed for %v", this), r)
+	})
+	return this.listener.method.invoke(this.ctx, this.listener.instance, reflect.ValueOf(event)), nil
+}
+
+// invokeAsync notifies the listener on the container-managed executor, or on
+// the caller goroutine once async events are drained. Failures are logged,
+// the event is skipped if the publishing context is done before delivery.
+func (this *ListenerInvoker) invokeAsync(event any) {
+	async := &ListenerInvoker{this.context, this.ctx, this.listener, LoggingErrorHandler{}}
+	description := fmt.Sprintf("%T for %v", event, this)
+	notify := func() {
+		if e := this.ctx.Err(); e != nil {
+			slog.Debug(fmt.Sprintf("ioc.ApplicationContext: skipping async event %s. %v", description, e))
+			return
+		}
+		if e := async.Invoke(event); e != nil {
+			async.errorHandler.HandleError(e)
+		}
+	}
+	executor := this.context.asyncEventExecutor()
+	if executor == nil || !executor.execute(this.ctx, notify, description) {
+		notify()
+	}
+}
+
+// Implements String
+func (this *ListenerInvoker) String() string {
+	if this.listener.beanDefinition == nil {
+		return fmt.Sprintf("function listener of %v", this.listener.method.eventType)
+	}
+	return fmt.Sprintf("bean %v", this.listener.beanDefinition)
+}
@@ -0,0 +1,61 @@
+package ioc
+
+import (
+	"github.com/expr-lang/expr"
+	"github.com/go-jang/go/lang"
+	"github.com/go-jang/go/util/optional"
+)
+
+// Option of a listener registered with EventListener
+type ListenerOption func(*eventListenerMethod)
+
+// Async dispatches the event to the listener on the container-managed executor
+// instead of the publisher goroutine. Listener failures are logged.
+func Async() ListenerOption {
+	return func(listener *eventListenerMethod) {
+		listener.async = true
+	}
+}
+
+// InstantiateOnEvent creates the lazy or not yet created singleton bean on the
+// first publish of a matching event, otherwise only created beans are notified
+func InstantiateOnEvent() ListenerOption {
+	return func(listener *eventListenerMethod) {
+		listener.instantiate = true
+	}
+}
+
+// Sticky replays the last ContextRefreshedEvent, ApplicationStartedEvent and
+// ApplicationReadyEvent to the bean created after they were published, like
+// readiness which has already happened
+func Sticky() ListenerOption {
+	return func(listener *eventListenerMethod) {
+		listener.sticky = true
+	}
+}
+
+// ListenerOrder orders the listener among listeners of all beans and functions,
+// instead of the bean Order(...) or Ordered. Lower values are notified first:
+//
+//	EventListener((*Cache).OnConfigChanged, ioc.ListenerOrder(-10))
+func ListenerOrder(order int) ListenerOption {
+	return func(listener *eventListenerMethod) {
+		lang.Assert(listener.order == nil, "ListenerOrder is defined twice")
+		listener.order = &order
+	}
+}
+
+// When delivers only events matching the expr-lang condition, the event is
+// available as event:
+//
+//	EventListener((*Fraud).OnPayment, ioc.When("event.Amount > 1000"))
+//
+// See expr-lang: https://expr-lang.org/docs/language-definition
+func When(condition string) ListenerOption {
+	program := optional.OfCommaErr(expr.Compile(condition, expr.AsBool())).OrElsePanic("Cannot compile listener condition '%s'", condition)
+	return func(listener *eventListenerMethod) {
+		lang.Assert(listener.condition == nil, "When is defined twice")
+		listener.condition = program
+		listener.conditionSource = condition
+	}
+}
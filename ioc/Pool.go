@@ -0,0 +1,167 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-errr/go/err"
+	"github.com/go-jang/go/lang"
+	"github.com/go-jang/go/util/concurrent"
+)
+
+// Pool of pool scope bean instances, for non-thread-safe objects like parsers,
+// encoders or heavy buffers. Pool scope beans are resolved and injected as
+// the pool handle:
+//
+//	ioc.Bean[*Parser]().Scope("pool").PoolSize(2, 8).Factory(NewParser).Register()
+//
+//	type Handler struct {
+//		parsers *ioc.Pool[*Parser] `inject:""`
+//	}
+//
+//	parser, e := this.parsers.Borrow(ctx)
+//	if e != nil {
+//		return e
+//	}
+//	defer this.parsers.Return(parser)
+type Pool[T any] struct {
+	bean  *BeanDefinitionImpl[T]
+	idle  chan T
+	slots chan struct{} // one slot per created instance, borrowed or idle
+	done  chan struct{}
+	mutex sync.Mutex
+}
+
+var poolPkgPath = lang.TypeOf[Pool[any]]().PkgPath()
+
+// pooledType returns T for *Pool[T]
+func pooledType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct &&
+		t.Elem().PkgPath() == poolPkgPath && strings.HasPrefix(t.Elem().Name(), "Pool[") {
+		idle, _ := t.Elem().FieldByName("idle")
+		return idle.Type.Elem(), true
+	}
+	return nil, false
+}
+
+func newPool[T any](bean *BeanDefinitionImpl[T], min, max int) *Pool[T] {
+	pool := &Pool[T]{
+		bean:  bean,
+		idle:  make(chan T, max),
+		slots: make(chan struct{}, max),
+		done:  make(chan struct{}),
+	}
+	defer err.Catch(func(e any) {
+		pool.close()
+		panic(e)
+	})
+	for range min {
+		pool.slots <- struct{}{}
+		instance, e := pool.create()
+		if e != nil {
+			panic(e)
+		}
+		pool.idle <- instance
+	}
+	return pool
+}
+
+// Borrow an idle instance or create a new one if less than max instances exist.
+// Blocks until an instance is returned, the context is done or the pool is closed.
+func (this *Pool[T]) Borrow(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		var instance T
+		select {
+		case <-this.done:
+			return zero, err.NewIllegalStateException(fmt.Sprintf("Pool of %v is closed", this.bean))
+		case instance = <-this.idle:
+		default:
+			select {
+			case <-this.done:
+				return zero, err.NewIllegalStateException(fmt.Sprintf("Pool of %v is closed", this.bean))
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			case instance = <-this.idle:
+			case this.slots <- struct{}{}:
+				return this.create()
+			}
+		}
+		if e := this.validate(instance); e != nil {
+			slog.Warn(fmt.Sprintf("ioc.ApplicationContext: destroying invalid pooled bean %v. %s", this.bean, causeChain(e)))
+			this.destroy(instance)
+			continue
+		}
+		return instance, nil
+	}
+}
+
+// Return borrowed instance to the pool. Instances returned after
+// the pool is closed are destroyed, as well as instances returned to
+// a full pool, like by a second Return of the same instance.
+func (this *Pool[T]) Return(instance T) {
+	var closed, full bool
+	concurrent.Synchronized(&this.mutex, func() {
+		select {
+		case <-this.done:
+			closed = true
+		default:
+			select {
+			case this.idle <- instance:
+			default:
+				full = true
+			}
+		}
+	})
+	if closed {
+		this.destroy(instance)
+	} else if full {
+		// every slot is held by an idle instance, the returned one holds none
+		slog.Warn(fmt.Sprintf("ioc.ApplicationContext: destroying pooled bean %v returned to a full pool", this.bean))
+		this.bean.destroy(instance)
+	}
+}
+
+// create instantiates a new bean instance holding a taken slot
+func (this *Pool[T]) create() (instance T, e error) {
+	defer err.Catch(func(r any) {
+		<-this.slots
+		e = err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot create pooled bean %v", this.bean), r)
+	})
+	instance, _ = this.bean.newInstance().(T)
+	return instance, nil
+}
+
+func (this *Pool[T]) validate(instance T) (e error) {
+	if this.bean.validateMethod == nil {
+		return nil
+	}
+	defer err.Catch(func(r any) {
+		e = err.NewRuntimeExceptionFrom("Validate failed", r)
+	})
+	return this.bean.validateMethod(instance)
+}
+
+func (this *Pool[T]) destroy(instance T) {
+	this.bean.destroy(instance)
+	<-this.slots
+}
+
+// close destroys idle instances, borrowed instances are destroyed on return
+func (this *Pool[T]) close() {
+	concurrent.Synchronized(&this.mutex, func() {
+		close(this.done)
+	})
+	for {
+		select {
+		case instance := <-this.idle:
+			this.destroy(instance)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package ioc
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+)
+
+// Event observed by OnPublish on the publisher goroutine
+type PublishedEvent struct {
+	Event     any
+	Context   context.Context
+	Goroutine int64 // id of the publisher goroutine
+}
+
+// goroutineID parses the "goroutine N [running]:" stack header, only for
+// diagnostics of publish observers
+func goroutineID() int64 {
+	buffer := make([]byte, 64)
+	buffer = buffer[:runtime.Stack(buffer, false)]
+	buffer = bytes.TrimPrefix(buffer, []byte("goroutine "))
+	id, _ := strconv.ParseInt(string(buffer[:bytes.IndexByte(buffer, ' ')]), 10, 64)
+	return id
+}
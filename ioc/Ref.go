@@ -0,0 +1,46 @@
+package ioc
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-jang/go/lang"
+	refl "github.com/go-jang/go/lang/reflect"
+)
+
+// Ref holds the current instance of a singleton bean and observes
+// ApplicationContext.Replace, like a client with rotated credentials:
+//
+//	type Service struct {
+//		client *ioc.Ref[*Client] `inject:""`
+//	}
+//
+//	this.client.Get().Call()
+type Ref[T any] struct {
+	bean BeanDefinition
+}
+
+var refPkgPath = lang.TypeOf[Ref[any]]().PkgPath()
+
+// referencedType returns T for *Ref[T]
+func referencedType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct &&
+		t.Elem().PkgPath() == refPkgPath && strings.HasPrefix(t.Elem().Name(), "Ref[") {
+		get, _ := t.MethodByName("Get")
+		return get.Type.Out(0), true
+	}
+	return nil, false
+}
+
+// newRef creates *Ref[T] of the type t
+func newRef(t reflect.Type, bean BeanDefinition) any {
+	ref := reflect.New(t.Elem())
+	refl.Settable(ref.Elem().FieldByName("bean")).Set(reflect.ValueOf(bean))
+	return ref.Interface()
+}
+
+// Get returns the current bean instance
+func (this *Ref[T]) Get() T {
+	instance, _ := this.bean.getInstance().(T)
+	return instance
+}
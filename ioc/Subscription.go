@@ -0,0 +1,112 @@
+package ioc
+
+import (
+	"sync"
+
+	"github.com/go-jang/go/util/concurrent"
+)
+
+// Subscription channel behavior when the buffer is full
+type OverflowPolicy int
+
+const (
+	// Publisher waits for the subscriber
+	OverflowBlock OverflowPolicy = iota
+	// Oldest buffered event is discarded
+	OverflowDropOldest
+	// Published event is discarded
+	OverflowDropNewest
+)
+
+// Subscribe returns a channel receiving published events assignable to E, for
+// goroutines selecting on events alongside ioc.Context().Done():
+//
+//	events, cancel := ioc.Subscribe[*OrderPlacedEvent](16, ioc.OverflowDropOldest)
+//	defer cancel()
+//	for {
+//		select {
+//		case event, ok := <-events:
+//			...
+//		case <-ioc.Context().Done():
+//			return
+//		}
+//	}
+//
+// Events are sent by the publisher like by any listener. Default overflow
+// policy: OverflowBlock. Once the context is closing, events are sent only if
+// the buffer has room or the subscriber is receiving, so a subscriber that
+// stopped reading does not block ioc.Close(). The channel is closed by cancel
+// or on ioc.Close().
+func Subscribe[E any](bufferSize int, overflow ...OverflowPolicy) (<-chan E, func()) {
+	context := applicationContextInstance()
+	subscription := &subscription[E]{
+		events:   make(chan E, bufferSize),
+		done:     make(chan struct{}),
+		shutdown: context.shutdown,
+	}
+	if len(overflow) > 0 {
+		subscription.overflow = overflow[len(overflow)-1]
+	}
+	cancel := context.subscribe(subscription.send, subscription.close)
+	return subscription.events, cancel
+}
+
+type subscription[E any] struct {
+	events   chan E
+	overflow OverflowPolicy
+	done     chan struct{}
+	shutdown <-chan struct{}
+	closed   bool
+	mutex    sync.Mutex
+	once     sync.Once
+}
+
+func (this *subscription[E]) send(event E) {
+	concurrent.Synchronized(&this.mutex, func() {
+		if this.closed {
+			return
+		}
+		switch this.overflow {
+		case OverflowBlock:
+			select {
+			case this.events <- event:
+			case <-this.done:
+			case <-this.shutdown:
+				select {
+				case this.events <- event:
+				default:
+				}
+			}
+		case OverflowDropOldest:
+			for {
+				select {
+				case this.events <- event:
+					return
+				default:
+				}
+				select {
+				case <-this.events:
+				default:
+					// unbuffered channel without receiver
+					return
+				}
+			}
+		case OverflowDropNewest:
+			select {
+			case this.events <- event:
+			default:
+			}
+		}
+	})
+}
+
+// close unblocks the sender first, the channel is closed holding the mutex
+func (this *subscription[E]) close() {
+	this.once.Do(func() {
+		close(this.done)
+		concurrent.Synchronized(&this.mutex, func() {
+			this.closed = true
+			close(this.events)
+		})
+	})
+}
@@ -12,7 +12,7 @@
 //     BeanNameAware, EnvironmentAware, ApplicationContextAware, ...
 //
 //  3. Configuration and dependency injection
-//     value tags, inject tags, configuration binding.
+//     value tags, inject tags, inject methods, configuration binding.
 //
 //  4. PostConstruct
 //     Custom post-construct callback is invoked.
@@ -70,9 +70,27 @@ import (
 
 const InjectTag = "inject"
 const Optional = "optional"
+const Group = "group"
 
+// Provider returns the bean on invocation. Injected Provider fields and Inject
+// arguments resolve the bean on every call, so a longer-lived bean obtains
+// the current scoped instance, like a fresh prototype:
+//
+//	type Handler struct {
+//		request ioc.Provider[*Request] `inject:""`
+//	}
 type Provider[T any] func() T
 
+var providerPkgPath = lang.TypeOf[Provider[any]]().PkgPath()
+
+// providedType returns T for Provider[T]
+func providedType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Func && t.PkgPath() == providerPkgPath && strings.HasPrefix(t.Name(), "Provider[") {
+		return t.Out(0), true
+	}
+	return nil, false
+}
+
 // Bean creates a bean definition builder for the specified bean type.
 //
 // Bean is the primary entry point for registering container-managed beans
@@ -133,15 +151,130 @@ func Bean[T any]() *BeanDefinitionImpl[T] {
 //
 // For container-managed beans prefer declarative dependency injection using
 // inject tags instead of Resolve.
+//
+// Resolve terminates the process if the bean cannot be resolved. Libraries,
+// command-line tools with their own error handling and tests should prefer
+// ResolveE, MustResolve or ResolveAll.
 func Resolve[T any](name ...string) Provider[T] {
+	return qualifierOf[T](name...).resolveOrExit()
+}
+
+// ResolveE resolves the bean immediately and returns resolution failure
+// as an error instead of terminating the process:
+//
+//	service, e := ioc.ResolveE[MyService]()
+//	if e != nil {
+//		return e
+//	}
+//
+// Name and optional arguments have the same meaning as for Resolve.
+func ResolveE[T any](name ...string) (T, error) {
+	return qualifierOf[T](name...).resolveE()
+}
+
+// MustResolve resolves the bean immediately and panics if it cannot be
+// resolved. The panic can be handled with err.Catch.
+//
+// Name and optional arguments have the same meaning as for Resolve.
+func MustResolve[T any](name ...string) T {
+	return qualifierOf[T](name...).doResolve()
+}
+
+// ResolveAll resolves all beans assignable to T ordered by Order(...)
+// and Ordered, the same way slices are injected:
+//
+//	handlers, e := ioc.ResolveAll[Handler]()
+func ResolveAll[T any]() ([]T, error) {
+	return newInjectQualifier[[]T]().resolveE()
+}
+
+// BeanNamesForType returns names of registered beans assignable to T.
+// Beans registered without a name are not included, see
+// ApplicationContext.BeanDefinitions for all bean definitions.
+func BeanNamesForType[T any]() []string {
+	return applicationContextInstance().BeanNamesForType(lang.TypeOf[T]())
+}
+
+// Invoke calls the function with arguments resolved from the current
+// ApplicationContext and returns its results, for scripts and CLI commands
+// not worth a dedicated struct:
+//
+//	_, e := ioc.Invoke(func(repo Repo, log *slog.Logger, handlers []Handler) error {
+//		...
+//	})
+//
+// Arguments are resolved by type, slices collect all matching beans. An
+// argument of a struct type literal is a dependency group, its fields tagged
+// with inject are resolved by name and may be optional. Names and options are
+// not part of a Go type, so the group carries them in inject tags instead of
+// wrapper argument types:
+//
+//	ioc.Invoke(func(deps struct {
+//		Primary *sql.DB `inject:"primaryDB"`
+//		Cache   Cache   `inject:",optional"`
+//	}) { ... })
+//
+// An argument of a named struct type is a bean of that type.
+//
+// The error reports failed argument resolution or the non-nil error returned
+// by the function as its last result.
+func Invoke(f any) (results []any, e error) {
+	function := reflect.ValueOf(f)
+	if function.Kind() != reflect.Func || function.IsNil() {
+		panic(err.NewIllegalArgumentException(fmt.Sprintf("Function expected, got %T", f)))
+	}
+	functionType := function.Type()
+	lang.Assert(!functionType.IsVariadic(), "Invoke function must not be variadic")
+
+	args := make([]reflect.Value, 0, functionType.NumIn())
+	for i := 0; i < functionType.NumIn(); i++ {
+		arg, e := invokeArgument(functionType, i)
+		if e != nil {
+			return nil, e
+		}
+		args = append(args, arg)
+	}
+	for _, result := range function.Call(args) {
+		results = append(results, result.Interface())
+	}
+	if n := functionType.NumOut(); n > 0 && functionType.Out(n-1) == errorType {
+		e, _ = results[n-1].(error)
+	}
+	return results, e
+}
+
+var errorType = lang.TypeOf[error]()
+
+func invokeArgument(functionType reflect.Type, index int) (arg reflect.Value, e error) {
+	defer err.Catch(func(r any) {
+		e = err.NewRuntimeExceptionFrom(fmt.Sprintf("Cannot resolve argument %d of %s", index, functionType), r)
+	})
+	t := functionType.In(index)
+	if isStructLiteral(t) {
+		group := reflect.New(t)
+		injectStructFields(group.Elem(), "", map[injectVisit]bool{{group.Pointer(), group.Type()}: true}, nil)
+		return group.Elem(), nil
+	}
+	return reflect.ValueOf((&InjectQualifier[any]{t: t}).doResolve()), nil
+}
+
+// isStructLiteral reports whether the type is an unnamed struct type like
+// struct{ ... }, injected field by field rather than looked up as a bean
+func isStructLiteral(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.Name() == ""
+}
+
+func qualifierOf[T any](name ...string) *InjectQualifier[T] {
 	lang.Assert(len(name) <= 2, "Bean name and 'optional' expected")
+	qualifier := newInjectQualifier[T]()
 	if len(name) == 2 {
 		lang.Assert(name[1] == Optional, "Unsupported option '%s'", name[1])
-		return newInjectQualifier[T]().Name(name[0]).Optional().resolveOrExit()
-	} else if len(name) == 1 {
-		return newInjectQualifier[T]().Name(name[0]).resolveOrExit()
+		qualifier.Optional()
+	}
+	if len(name) >= 1 {
+		qualifier.Name(name[0])
 	}
-	return newInjectQualifier[T]().resolveOrExit()
+	return qualifier
 }
 
 // InjectBeans injects matching beans into struct fields tagged with
@@ -165,33 +298,114 @@ func Resolve[T any](name ...string) Provider[T] {
 //		test.Service.Process()
 //	}
 //
+// Embedded structs, non-nil embedded struct pointers and struct-valued fields
+// tagged with `inject:""` are processed recursively, so dependency bundles
+// can be shared across many services. A struct-valued field is injected as a
+// whole if a bean of the struct type is registered or the tag names a bean:
+//
+//	type Deps struct {
+//		Repo Repository `inject:""`
+//	}
+//
+//	type Service struct {
+//		Deps
+//		clients struct {
+//			http *http.Client `inject:""`
+//		} `inject:""`
+//	}
+//
 // InjectBeans is not part of the normal application runtime flow.
 // For container-managed application beans prefer ordinary dependency injection
 // performed automatically by the ApplicationContext.
 func InjectBeans[T any](target *T) *T {
-	injectBeansAny(target)
+	injectBeansAny(target, nil)
 	return target
 }
 
-func injectBeansAny(target any) any {
-	refl.ForEachTaggedField(target, InjectTag, func(field refl.Field) {
-		name, optional := parseInjectTag(field)
+// injectBeansAny injects dependencies of the owner bean, nil if the target is
+// not container-managed.
+func injectBeansAny(target any, owner BeanDefinition) any {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		panic(err.NewIllegalArgumentException(fmt.Sprintf("Target must be non-nil pointer to struct, got %T", target)))
+	}
+	visited := map[injectVisit]bool{{targetValue.Pointer(), targetValue.Type()}: true}
+	injectStructFields(targetValue.Elem(), "", visited, owner)
+	return target
+}
+
+// injectVisit identifies a struct reached through a pointer. The type is part
+// of the key because an embedded struct at offset 0 shares its owner's address.
+type injectVisit struct {
+	ptr uintptr
+	t   reflect.Type
+}
+
+// injectStructFields injects tagged fields of the struct and recurses into
+// embedded structs and dependency groups: fields of a struct type literal
+// tagged with inject:"" and struct fields tagged with inject:",group".
+// A field of a named struct type tagged with inject:"" is a bean.
+func injectStructFields(structValue reflect.Value, path string, visited map[injectVisit]bool, owner BeanDefinition) {
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		structField := structType.Field(i)
+		fieldValue := refl.Settable(structValue.Field(i))
+		fieldPath := path + structField.Name
+		tagValue, tagged := structField.Tag.Lookup(InjectTag)
+
+		if structField.Anonymous && !tagged && structField.Type.Kind() == reflect.Struct {
+			injectStructFields(fieldValue, fieldPath+".", visited, owner)
+			continue
+		}
+		if !tagged {
+			if structField.Anonymous && structField.Type.Kind() == reflect.Pointer &&
+				structField.Type.Elem().Kind() == reflect.Struct && !fieldValue.IsNil() {
+				visit := injectVisit{fieldValue.Pointer(), structField.Type}
+				if !visited[visit] {
+					visited[visit] = true
+					injectStructFields(fieldValue.Elem(), fieldPath+".", visited, owner)
+				}
+			}
+			continue
+		}
+
+		field := refl.Field{
+			Owner:    structType,
+			Field:    structField,
+			Index:    structField.Index,
+			Type:     structField.Type,
+			Value:    fieldValue,
+			TagName:  InjectTag,
+			TagValue: tagValue,
+		}
+		name, optional, group := parseInjectTag(field)
+		if group || strings.TrimSpace(tagValue) == "" && isStructLiteral(field.Type) {
+			lang.Assert(field.Type.Kind() == reflect.Struct, "Dependency group %s %s must be a struct", structField.Name, structField.Type)
+			lang.Assert(name == "" && !optional, "Dependency group %s %s must not be named or optional", structField.Name, structField.Type)
+			injectStructFields(fieldValue, fieldPath+".", visited, owner)
+			continue
+		}
 		qualifier := InjectQualifier[any]{
-			fieldName: field.Field.Name,
+			fieldName: fieldPath,
 			t:         field.Type,
 			name:      name,
 			optional:  optional,
+			owner:     owner,
 		}
 		bean := qualifier.resolve()()
 		if bean != nil {
 			field.Value.Set(reflect.ValueOf(bean))
 		}
-	})
-	return target
+	}
 }
 
-func parseInjectTag(field refl.Field) (name string, optional bool) {
-	parts := strings.Split(field.TagValue, ",")
+func parseInjectTag(field refl.Field) (name string, optional bool, group bool) {
+	return parseInjectQualifier(field.TagValue, fmt.Sprintf("%s %s", field.Field.Name, field.Field.Type))
+}
+
+// parseInjectQualifier parses "name,option..." in the format of the inject tag
+func parseInjectQualifier(qualifier string, target string) (name string, optional bool, group bool) {
+	parts := strings.Split(qualifier, ",")
 	if len(parts) > 0 {
 		name = strings.TrimSpace(parts[0])
 	}
@@ -202,11 +416,13 @@ func parseInjectTag(field refl.Field) (name string, optional bool) {
 			continue
 		case Optional:
 			optional = true
+		case Group:
+			group = true
 		default:
-			panic(err.NewIllegalArgumentException(fmt.Sprintf("Unsupported inject option '%s' used for %s %s", option, field.Field.Name, field.Field.Type)))
+			panic(err.NewIllegalArgumentException(fmt.Sprintf("Unsupported inject option '%s' used for %s", option, target)))
 		}
 	}
-	return name, optional
+	return name, optional, group
 }
 
 // Context returns the root context of the current ApplicationContext.
@@ -240,6 +456,27 @@ func Context() context.Context {
 	return applicationContextInstance().context
 }
 
+// OnEvent registers the function receiving events assignable to its argument
+// type, for code not owning a bean registration. Accepts the same options and
+// return values as EventListener:
+//
+//	ioc.OnEvent(func(event *ioc.ApplicationReadyEvent) {
+//		slog.Info("ready")
+//	})
+//
+// Function listeners are notified after bean listeners.
+func OnEvent(function any, options ...ListenerOption) {
+	applicationContextInstance().OnEvent(function, options...)
+}
+
+// OnPublish registers the observer called on the publisher goroutine with
+// every published event, including lifecycle events, before listeners are
+// notified. Intended for diagnostics and tests, see iocttest.RecordEvents.
+// The returned function removes the observer.
+func OnPublish(observer func(published PublishedEvent)) (remove func()) {
+	return applicationContextInstance().OnPublish(observer)
+}
+
 // Refresh initializes the current ApplicationContext.
 //
 // Refresh is the low-level container initialization operation, similar to
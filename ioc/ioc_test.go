@@ -1,12 +1,23 @@
 package ioc_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-beans/go/ioc"
+	"github.com/go-beans/go/ioc/iocttest"
+	"github.com/go-errr/go/err"
 	"github.com/go-external-config/go/env"
 	"github.com/go-jang/go/util/optional"
 	"github.com/stretchr/testify/mock"
@@ -14,9 +25,13 @@ import (
 )
 
 func TestMain(m *testing.M) {
+	if os.Getenv(isolatedTestEnv) != "" {
+		// the isolated test registers its own beans
+		m.Run()
+		ioc.Close()
+		return
+	}
 	fmt.Println("Before all")
-	env.SetActiveProfiles("test")
-
 	ioc.Bean[*Counter]().Name("singletonCounter", "counter").Factory(NewCounter).Register()
 	ioc.Bean[*Counter]().Scope("prototype").Name("prototypeCounter").Factory(NewCounter).Register()
 
@@ -43,6 +58,120 @@ func TestMain(m *testing.M) {
 		return &m
 	}).Register()
 
+	ioc.Bean[*SetterService]().Factory(NewSetterService).
+		Inject((*SetterService).SetCalculator).
+		Inject(func(s *SetterService, operations []Operation, counter *Counter) {
+			s.operations, s.counter = operations, counter
+		}, "", "missingCounter,optional").
+		PostConstruct((*SetterService).PostConstruct).Register()
+
+	ioc.Bean[*UnreachableClient]().Lazy().FactoryE(func() (*UnreachableClient, error) {
+		return nil, errConnectionRefused
+	}).Register()
+	ioc.Bean[*Counter]().Name("invalidCounter").Lazy().Factory(NewCounter).PostConstructE(func(counter *Counter) error {
+		return errInvalidCounter
+	}).Register()
+
+	ioc.Bean[*StuckClient]().Lazy().CreateTimeout(50 * time.Millisecond).FactoryContext(func(ctx context.Context) (*StuckClient, error) {
+		// ignores ctx, the client is returned after the creation timeout
+		time.Sleep(200 * time.Millisecond)
+		return &StuckClient{}, nil
+	}).PreDestroy(func(client *StuckClient) {
+		stuckClientsDestroyed.Add(1)
+	}).Register()
+
+	sidecarAttempts := 0
+	ioc.Bean[*SidecarClient]().Lazy().Retry(3, time.Millisecond).FactoryE(func() (*SidecarClient, error) {
+		sidecarAttempts++
+		if sidecarAttempts < 3 {
+			return nil, errConnectionRefused
+		}
+		return &SidecarClient{attempts: sidecarAttempts}, nil
+	}).Register()
+	ioc.Bean[*Counter]().Name("retriedCounter").Lazy().Retry(2, time.Millisecond).Factory(NewCounter).PostConstructE(func(counter *Counter) error {
+		counter.count++
+		return errInvalidCounter
+	}).Register()
+
+	ioc.Bean[*ContextHolder]().Lazy().Factory(func() *ContextHolder { return &ContextHolder{} }).Register()
+
+	ioc.Bean[*PingListener]().Factory(func() *PingListener { return &PingListener{} }).EventListener((*PingListener).OnPing).Register()
+	for _, name := range []string{"concurrentService1", "concurrentService2", "concurrentService3"} {
+		ioc.Bean[*ConcurrentService]().Name(name).Lazy().Factory(func() *ConcurrentService {
+			time.Sleep(time.Millisecond)
+			return &ConcurrentService{}
+		}).EventListener((*ConcurrentService).OnPing).Register()
+	}
+
+	ioc.Bean[Ticket]().Scope("prototype").Factory(NewTicket).ScopedProxy(func(target ioc.Provider[Ticket]) Ticket {
+		return ticketProxy{target}
+	}).Register()
+	ioc.Bean[*TicketDesk]().Lazy().Factory(func() *TicketDesk { return &TicketDesk{} }).Register()
+	ioc.Bean[*CounterHolder]().Name("warnedHolder").Lazy().Factory(func() *CounterHolder { return &CounterHolder{} }).Register()
+	ioc.Bean[*CounterHolder]().Name("failingHolder").Lazy().Factory(func() *CounterHolder { return &CounterHolder{} }).Register()
+
+	ioc.Bean[*Parser]().Scope("pool").PoolSize(1, 2).Factory(func() *Parser { return &Parser{} }).
+		Validate(func(parser *Parser) error {
+			if parser.broken {
+				return errBrokenParser
+			}
+			return nil
+		}).
+		PreDestroy(func(parser *Parser) { parsersDestroyed.Add(1) }).Register()
+
+	ioc.Bean[*Credentials]().Name("credentials").Lazy().Factory(func() *Credentials { return &Credentials{token: "initial"} }).
+		PreDestroy(func(credentials *Credentials) { credentials.destroyed.Store(true) }).Register()
+	ioc.Bean[*CredentialsConsumer]().Lazy().Factory(func() *CredentialsConsumer { return &CredentialsConsumer{} }).
+		EventListener((*CredentialsConsumer).OnReplaced).Register()
+
+	ioc.Bean[*AuditListener]().Factory(func() *AuditListener { return &AuditListener{} }).
+		EventListener((*AuditListener).OnUserCreated, ioc.Async()).
+		AsyncEventListener((*AuditListener).OnUserCreatedFailing).Register()
+
+	ioc.Bean[*FraudListener]().Factory(func() *FraudListener { return &FraudListener{} }).
+		EventListener((*FraudListener).OnPayment, ioc.When("event.Amount > 1000")).Register()
+
+	ioc.Bean[*OrderFlow]().Factory(func() *OrderFlow { return &OrderFlow{} }).
+		EventListener((*OrderFlow).OnOrderPlaced).
+		EventListener((*OrderFlow).OnPaymentRequested).
+		EventListener((*OrderFlow).OnReceipt).
+		EventListener((*OrderFlow).OnShipping).Register()
+
+	ioc.Bean[*CountingMulticaster]().Name(ioc.ApplicationEventMulticasterBeanName).Factory(func() *CountingMulticaster { return &CountingMulticaster{} }).Register()
+	ioc.Bean[*SwitchableErrorHandler]().Name(ioc.EventErrorHandlerBeanName).Factory(func() *SwitchableErrorHandler { return &SwitchableErrorHandler{} }).Register()
+	ioc.Bean[*AuditErrorHandler]().Factory(func() *AuditErrorHandler { return &AuditErrorHandler{} }).Register()
+
+	ioc.Bean[*DeployWatcher]().Factory(func() *DeployWatcher { return &DeployWatcher{} }).Register()
+
+	ioc.Bean[*ReadinessGate]().Lazy().Factory(func() *ReadinessGate { return &ReadinessGate{} }).
+		EventListener((*ReadinessGate).OnReady, ioc.Sticky()).Register()
+	ioc.Bean[*MigrationJob]().Name("migrationJob").Lazy().Factory(func() *MigrationJob { return &MigrationJob{} }).
+		EventListener((*MigrationJob).OnMigration, ioc.InstantiateOnEvent()).Register()
+
+	ioc.Bean[*TraceListener]().Factory(func() *TraceListener { return &TraceListener{} }).
+		EventListener((*TraceListener).OnTraced).
+		EventListener((*TraceListener).OnTracedAsync, ioc.Async()).Register()
+	ioc.Bean[*ShippingListener]().Factory(func() *ShippingListener { return &ShippingListener{} }).Order(1).
+		EventListener((*ShippingListener).Reserve, ioc.ListenerOrder(-10)).
+		EventListener((*ShippingListener).Ship).Register()
+	ioc.Bean[*BillingListener]().Factory(func() *BillingListener { return &BillingListener{} }).Order(0).
+		EventListener((*BillingListener).Charge).Register()
+	ioc.Bean[*WarmCache]().Lazy().Factory(func() *WarmCache { return &WarmCache{} }).
+		PostConstruct(func(cache *WarmCache) {
+			time.Sleep(50 * time.Millisecond)
+			cache.warm.Store(true)
+		}).Register()
+	ioc.Bean[*LeftNode]().Lazy().Factory(func() *LeftNode {
+		time.Sleep(20 * time.Millisecond)
+		return &LeftNode{}
+	}).Register()
+	ioc.Bean[*RightNode]().Lazy().Factory(func() *RightNode {
+		time.Sleep(20 * time.Millisecond)
+		return &RightNode{}
+	}).Register()
+	ioc.Bean[ServerConfig]().Name("primaryConfig").Factory(func() ServerConfig { return ServerConfig{URL: "http://primary"} }).Register()
+	ioc.Bean[*PooledEncoder]().Scope("pool").Factory(func() *PooledEncoder { return &PooledEncoder{} }).Register()
+	ioc.Bean[*PlainEncoder]().Factory(func() *PlainEncoder { return &PlainEncoder{} }).Register()
 	ioc.Bean[*http.Client]().Factory(func() *http.Client {
 		return &http.Client{
 			Timeout: 60 * time.Second,
@@ -55,12 +184,26 @@ func TestMain(m *testing.M) {
 		}
 	}).Register()
 
+	// profiles are evaluated on first resolution, activating them after registration is effective
+	env.SetActiveProfiles("test").WithPropertySource(testProperties)
+
 	m.Run()
 
 	fmt.Println("After all")
 	ioc.Close()
 }
 
+// properties set by tests with withProperty, defaults apply otherwise
+var testProperties = env.MapPropertySourceOf("test")
+
+// withProperty sets the property until the test ends
+func withProperty(t *testing.T, key, value string) {
+	testProperties.SetProperty(key, value)
+	t.Cleanup(func() {
+		delete(testProperties.Properties(), key)
+	})
+}
+
 func Test_Ioc(t *testing.T) {
 	t.Run("general examples", func(t *testing.T) {
 		preinitializedMap := ioc.Resolve[*map[string]string]("preinitializedMap")
@@ -131,6 +274,1125 @@ func Test_IocCalculatorMock(t *testing.T) {
 	})
 }
 
+func Test_IocNestedInjection(t *testing.T) {
+	t.Run("embedded structs and dependency groups injected recursively", func(t *testing.T) {
+		consumer := &NestedConsumer{}
+		consumer.NestedConsumer = consumer
+		ioc.InjectBeans(consumer)
+
+		require.NotNil(t, consumer.calculator)
+		require.NotNil(t, consumer.operations.add)
+		require.NotNil(t, consumer.operations.divide)
+		require.Same(t, consumer.calculator, ioc.Resolve[Calculator]()())
+		require.Same(t, consumer.calculator, consumer.deps.calculator)
+	})
+
+	t.Run("named struct type without bean is not a dependency group", func(t *testing.T) {
+		defer func() {
+			require.Contains(t, err.PrintStackTrace(recover()), "No bean of type ioc_test.MailConfig found")
+		}()
+		ioc.InjectBeans(&struct {
+			config MailConfig `inject:""`
+		}{})
+	})
+}
+
+type ServerConfig struct {
+	URL string
+}
+
+func Test_IocValueTypeStructBean(t *testing.T) {
+	t.Run("registered struct bean injected as a whole", func(t *testing.T) {
+		consumer := ioc.InjectBeans(&struct {
+			config ServerConfig `inject:""`
+			named  ServerConfig `inject:"primaryConfig"`
+		}{})
+
+		require.Equal(t, "http://primary", consumer.config.URL)
+		require.Equal(t, "http://primary", consumer.named.URL)
+	})
+
+	t.Run("registered struct bean passed to Invoke", func(t *testing.T) {
+		results, e := ioc.Invoke(func(config ServerConfig) string { return config.URL })
+		require.NoError(t, e)
+		require.Equal(t, []any{"http://primary"}, results)
+	})
+}
+
+func Test_IocMethodInjection(t *testing.T) {
+	t.Run("inject methods invoked before post construct", func(t *testing.T) {
+		service := ioc.Resolve[*SetterService]()()
+
+		require.Same(t, ioc.Resolve[Calculator]()(), service.calculator)
+		require.Equal(t, 4, len(service.operations))
+		require.Nil(t, service.counter)
+		require.True(t, service.injectedBeforePostConstruct)
+	})
+}
+
+type SetterService struct {
+	calculator                  Calculator
+	operations                  []Operation
+	counter                     *Counter
+	injectedBeforePostConstruct bool
+}
+
+func NewSetterService() *SetterService {
+	return &SetterService{}
+}
+func (this *SetterService) SetCalculator(calculator Calculator) {
+	this.calculator = calculator
+}
+func (this *SetterService) PostConstruct() {
+	this.injectedBeforePostConstruct = this.calculator != nil && this.operations != nil
+}
+
+func Test_IocAutowireByFieldName(t *testing.T) {
+	t.Run("ambiguous candidates fail by default", func(t *testing.T) {
+		defer func() {
+			require.Contains(t, err.PrintStackTrace(recover()), "Multiple beans of type ioc_test.Operation found. Use name qualifier")
+		}()
+		ioc.InjectBeans(&FieldNameConsumer{})
+	})
+
+	t.Run("ambiguous candidates resolved by field name", func(t *testing.T) {
+		withProperty(t, "ioc.autowire-by-field-name", "true")
+		consumer := ioc.InjectBeans(&FieldNameConsumer{})
+
+		require.Same(t, ioc.Resolve[*AddOperation]()(), consumer.addOperation)
+		require.Same(t, ioc.Resolve[*DivideOperation]()(), consumer.divideOperation)
+	})
+}
+
+type FieldNameConsumer struct {
+	addOperation    Operation `inject:""`
+	divideOperation Operation `inject:""`
+}
+
+func Test_IocResolveVariants(t *testing.T) {
+	t.Run("resolution failures returned as errors", func(t *testing.T) {
+		calculator, e := ioc.ResolveE[Calculator]()
+		require.NoError(t, e)
+		require.Same(t, ioc.MustResolve[Calculator](), calculator)
+
+		_, e = ioc.ResolveE[*Counter]("missingCounter")
+		require.Error(t, e)
+		counter, e := ioc.ResolveE[*Counter]("missingCounter", ioc.Optional)
+		require.NoError(t, e)
+		require.Nil(t, counter)
+
+		_, e = ioc.ResolveE[*UnreachableClient]()
+		require.ErrorIs(t, e, errConnectionRefused)
+		require.Panics(t, func() { ioc.MustResolve[*UnreachableClient]() })
+
+		operations, e := ioc.ResolveAll[Operation]()
+		require.NoError(t, e)
+		require.Equal(t, 4, len(operations))
+		require.IsType(t, &DivideOperation{}, operations[0])
+	})
+}
+
+type StuckClient struct{}
+
+var stuckClientsDestroyed atomic.Int32
+
+func Test_IocCreateTimeout(t *testing.T) {
+	t.Run("stuck factory aborted with creation timeout", func(t *testing.T) {
+		threshold := time.Now()
+		destroyed := stuckClientsDestroyed.Load()
+		_, e := ioc.ResolveE[*StuckClient]()
+
+		require.ErrorIs(t, e, context.DeadlineExceeded)
+		require.Contains(t, err.PrintStackTrace(e), "Bean creation timed out after 50ms: *ioc_test.StuckClient")
+		require.Less(t, time.Since(threshold), 200*time.Millisecond)
+
+		// instance returned after the timeout is destroyed, not leaked
+		require.Eventually(t, func() bool { return stuckClientsDestroyed.Load() == destroyed+1 }, time.Second, time.Millisecond)
+	})
+}
+
+type SidecarClient struct {
+	attempts int
+}
+
+func Test_IocRetry(t *testing.T) {
+	t.Run("factory retried until sidecar is ready", func(t *testing.T) {
+		client, e := ioc.ResolveE[*SidecarClient]()
+		require.NoError(t, e)
+		require.Equal(t, 3, client.attempts)
+	})
+	t.Run("final error preserved when attempts exhausted", func(t *testing.T) {
+		_, e := ioc.ResolveE[*Counter]("retriedCounter")
+		require.ErrorIs(t, e, errInvalidCounter)
+	})
+}
+
+type Ticket interface {
+	ID() int32
+}
+
+var ticketSequence atomic.Int32
+
+type ticket struct {
+	id int32
+}
+
+func NewTicket() Ticket {
+	return &ticket{ticketSequence.Add(1)}
+}
+
+func (this *ticket) ID() int32 {
+	return this.id
+}
+
+type ticketProxy struct {
+	target ioc.Provider[Ticket]
+}
+
+func (this ticketProxy) ID() int32 {
+	return this.target().ID()
+}
+
+type TicketDesk struct {
+	ticket   Ticket                 `inject:""`
+	counters ioc.Provider[*Counter] `inject:"prototypeCounter"`
+	counter  *Counter               `inject:"prototypeCounter"`
+}
+
+func Test_IocScopeMismatch(t *testing.T) {
+	desk := ioc.MustResolve[*TicketDesk]()
+
+	t.Run("scoped proxy re-resolves prototype on each call", func(t *testing.T) {
+		require.IsType(t, ticketProxy{}, desk.ticket)
+		require.NotEqual(t, desk.ticket.ID(), desk.ticket.ID())
+		require.IsType(t, &ticket{}, ioc.MustResolve[Ticket]())
+	})
+
+	t.Run("provider re-resolves prototype on each call", func(t *testing.T) {
+		require.NotSame(t, desk.counters(), desk.counters())
+	})
+
+	t.Run("prototype without proxy injected once", func(t *testing.T) {
+		require.NotNil(t, desk.counter)
+		require.NotSame(t, desk.counter, desk.counters())
+	})
+
+	t.Run("mismatch logged by default", func(t *testing.T) {
+		logs := captureLogs(t)
+		ioc.MustResolve[*CounterHolder]("warnedHolder")
+		require.Contains(t, logs.String(), "prototype bean *ioc_test.Counter [prototype prototypeCounter] injected into singleton bean *ioc_test.CounterHolder [singleton warnedHolder lazy] is never re-resolved")
+	})
+
+	t.Run("mismatch fails with fail policy", func(t *testing.T) {
+		withProperty(t, "ioc.scope-mismatch", "fail")
+		_, e := ioc.ResolveE[*CounterHolder]("failingHolder")
+		require.Contains(t, err.PrintStackTrace(e), "injected into singleton bean *ioc_test.CounterHolder [singleton failingHolder lazy] is never re-resolved")
+	})
+}
+
+type CounterHolder struct {
+	counter *Counter `inject:"prototypeCounter"`
+}
+
+// logBuffer is written by the slog handler and read by the test
+type logBuffer struct {
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func (this *logBuffer) Write(p []byte) (int, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.buffer.Write(p)
+}
+
+func (this *logBuffer) String() string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.buffer.String()
+}
+
+// captureLogs records slog output until the test ends
+func captureLogs(t *testing.T) *logBuffer {
+	logs := &logBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+	})
+	return logs
+}
+
+type Parser struct {
+	broken bool
+}
+
+var errBrokenParser = errors.New("broken parser")
+var parsersDestroyed atomic.Int32
+
+func Test_IocPool(t *testing.T) {
+	pool := ioc.MustResolve[*ioc.Pool[*Parser]]()
+	require.Same(t, pool, ioc.MustResolve[*ioc.Pool[*Parser]]())
+
+	t.Run("borrow blocks at max size", func(t *testing.T) {
+		first, e := pool.Borrow(context.Background())
+		require.NoError(t, e)
+		second, e := pool.Borrow(context.Background())
+		require.NoError(t, e)
+		require.NotSame(t, first, second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, e = pool.Borrow(ctx)
+		require.ErrorIs(t, e, context.DeadlineExceeded)
+
+		pool.Return(first)
+		borrowed, e := pool.Borrow(context.Background())
+		require.NoError(t, e)
+		require.Same(t, first, borrowed)
+		pool.Return(borrowed)
+		pool.Return(second)
+	})
+
+	t.Run("invalid instance replaced on borrow", func(t *testing.T) {
+		destroyed := parsersDestroyed.Load()
+		parser, _ := pool.Borrow(context.Background())
+		parser.broken = true
+		pool.Return(parser)
+		other, _ := pool.Borrow(context.Background())
+		pool.Return(other)
+
+		for range 2 {
+			borrowed, e := pool.Borrow(context.Background())
+			require.NoError(t, e)
+			require.False(t, borrowed.broken)
+			defer pool.Return(borrowed)
+		}
+		require.Equal(t, destroyed+1, parsersDestroyed.Load())
+	})
+
+	t.Run("instance returned to a full pool destroyed", func(t *testing.T) {
+		first, _ := pool.Borrow(context.Background())
+		second, _ := pool.Borrow(context.Background())
+		pool.Return(first)
+		pool.Return(second)
+
+		destroyed := parsersDestroyed.Load()
+		foreign := &Parser{}
+		returned := make(chan struct{})
+		go func() {
+			pool.Return(foreign)
+			close(returned)
+		}()
+		select {
+		case <-returned:
+		case <-time.After(time.Second):
+			require.FailNow(t, "Return blocked on a full pool")
+		}
+		require.Equal(t, destroyed+1, parsersDestroyed.Load())
+
+		for range 2 {
+			borrowed, e := pool.Borrow(context.Background())
+			require.NoError(t, e)
+			require.NotSame(t, foreign, borrowed)
+			defer pool.Return(borrowed)
+		}
+	})
+
+	t.Run("pooled bean resolved through pool only", func(t *testing.T) {
+		_, e := ioc.ResolveE[*Parser]()
+		require.Contains(t, err.PrintStackTrace(e), "resolve *ioc.Pool[*ioc_test.Parser] instead")
+	})
+
+	t.Run("pooled bean excluded from slices", func(t *testing.T) {
+		encoders, e := ioc.ResolveAll[Encoder]()
+		require.NoError(t, e)
+		require.Len(t, encoders, 1)
+		require.IsType(t, &PlainEncoder{}, encoders[0])
+	})
+
+	t.Run("pooled Lifecycle bean rejected", func(t *testing.T) {
+		require.PanicsWithError(t, "Pool scope bean *ioc_test.LifecycleEncoder must not implement Lifecycle", func() {
+			ioc.Bean[*LifecycleEncoder]().Scope("pool").Factory(func() *LifecycleEncoder { return &LifecycleEncoder{} }).Register()
+		})
+	})
+}
+
+type Encoder interface {
+	Encode(value string) string
+}
+
+type PooledEncoder struct{}
+
+func (this *PooledEncoder) Encode(value string) string { return value }
+
+type PlainEncoder struct{}
+
+func (this *PlainEncoder) Encode(value string) string { return value }
+
+type LifecycleEncoder struct{}
+
+func (this *LifecycleEncoder) Start() {}
+func (this *LifecycleEncoder) Stop()  {}
+
+type Credentials struct {
+	token     string
+	destroyed atomic.Bool
+}
+
+type CredentialsConsumer struct {
+	credentials *ioc.Ref[*Credentials] `inject:""`
+	replaced    atomic.Pointer[ioc.BeanReplacedEvent]
+}
+
+func (this *CredentialsConsumer) OnReplaced(event *ioc.BeanReplacedEvent) {
+	this.replaced.Store(event)
+}
+
+func Test_IocReplace(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	consumer := ioc.MustResolve[*CredentialsConsumer]()
+	initial := consumer.credentials.Get()
+	require.Equal(t, "initial", initial.token)
+
+	rotated := &Credentials{token: "rotated"}
+	ctx.Replace("credentials", rotated)
+
+	require.Same(t, rotated, consumer.credentials.Get())
+	require.Same(t, rotated, ioc.MustResolve[*Credentials]("credentials"))
+	require.Eventually(t, initial.destroyed.Load, time.Second, time.Millisecond)
+	require.False(t, rotated.destroyed.Load())
+
+	event := consumer.replaced.Load()
+	require.Equal(t, "credentials", event.Name)
+	require.Same(t, initial, event.OldInstance)
+	require.Same(t, rotated, event.NewInstance)
+}
+
+func Test_IocInvoke(t *testing.T) {
+	t.Run("arguments resolved and results returned", func(t *testing.T) {
+		results, e := ioc.Invoke(func(calculator Calculator, operations []Operation, deps struct {
+			Counter     *Counter           `inject:"singletonCounter"`
+			Unreachable *UnreachableClient `inject:"missingClient,optional"`
+		}) (int, int, bool) {
+			return calculator.Add(1, 2), len(operations), deps.Counter != nil && deps.Unreachable == nil
+		})
+		require.NoError(t, e)
+		require.Equal(t, []any{3, 4, true}, results)
+	})
+
+	t.Run("function error returned", func(t *testing.T) {
+		results, e := ioc.Invoke(func(calculator Calculator) error {
+			return errInvalidCounter
+		})
+		require.ErrorIs(t, e, errInvalidCounter)
+		require.Len(t, results, 1)
+	})
+
+	t.Run("unresolvable argument reported", func(t *testing.T) {
+		invoked := false
+		_, e := ioc.Invoke(func(calculator Calculator, missing *FieldNameConsumer) {
+			invoked = true
+		})
+		require.Contains(t, err.PrintStackTrace(e), "Cannot resolve argument 1 of func(ioc_test.Calculator, *ioc_test.FieldNameConsumer)")
+		require.False(t, invoked)
+	})
+
+	t.Run("named struct type without bean reported", func(t *testing.T) {
+		invoked := false
+		_, e := ioc.Invoke(func(config MailConfig) {
+			invoked = true
+		})
+		require.Contains(t, err.PrintStackTrace(e), "No bean of type ioc_test.MailConfig found")
+		require.False(t, invoked)
+	})
+}
+
+type UserCreatedEvent struct {
+	release chan struct{}
+}
+
+type AuditListener struct {
+	audited atomic.Int32
+}
+
+func (this *AuditListener) OnUserCreated(event *UserCreatedEvent) {
+	<-event.release
+	this.audited.Add(1)
+}
+
+func (this *AuditListener) OnUserCreatedFailing(event *UserCreatedEvent) {
+	panic("audit storage unavailable")
+}
+
+func Test_IocAsyncEventListener(t *testing.T) {
+	listener := ioc.MustResolve[*AuditListener]()
+	event := &UserCreatedEvent{release: make(chan struct{})}
+	// a failed assertion must not leave the listener blocking ioc.Close
+	release := sync.OnceFunc(func() { close(event.release) })
+	t.Cleanup(release)
+
+	ioc.MustResolve[*ContextHolder]().ctx.PublishEvent(event)
+	require.Zero(t, listener.audited.Load())
+
+	release()
+	require.Eventually(t, func() bool { return listener.audited.Load() == 1 }, time.Second, time.Millisecond)
+}
+
+type PaymentEvent struct {
+	Amount int
+}
+
+type FraudListener struct {
+	checked []int
+}
+
+func (this *FraudListener) OnPayment(event *PaymentEvent) {
+	this.checked = append(this.checked, event.Amount)
+}
+
+func Test_IocConditionalEventListener(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	listener := ioc.MustResolve[*FraudListener]()
+
+	ctx.PublishEvent(&PaymentEvent{Amount: 500})
+	ctx.PublishEvent(&PaymentEvent{Amount: 5000})
+	require.Equal(t, []int{5000}, listener.checked)
+
+	require.Panics(t, func() { ioc.When("event.Amount >") })
+}
+
+type OrderPlacedEvent struct {
+	invalid bool
+}
+type PaymentRequestedEvent struct{}
+type ReceiptEvent struct{}
+type ShippingEvent struct{}
+
+var errInvalidOrder = errors.New("invalid order")
+
+type OrderFlow struct {
+	steps []string
+}
+
+func (this *OrderFlow) OnOrderPlaced(event *OrderPlacedEvent) (*PaymentRequestedEvent, error) {
+	if event.invalid {
+		return nil, errInvalidOrder
+	}
+	this.steps = append(this.steps, "placed")
+	return &PaymentRequestedEvent{}, nil
+}
+
+func (this *OrderFlow) OnPaymentRequested(event *PaymentRequestedEvent) []any {
+	this.steps = append(this.steps, "payment")
+	return []any{&ReceiptEvent{}, nil, &ShippingEvent{}}
+}
+
+func (this *OrderFlow) OnReceipt(event *ReceiptEvent) {
+	this.steps = append(this.steps, "receipt")
+}
+
+func (this *OrderFlow) OnShipping(event *ShippingEvent) {
+	this.steps = append(this.steps, "shipping")
+}
+
+func Test_IocListenerReturnValues(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	flow := ioc.MustResolve[*OrderFlow]()
+
+	t.Run("returned events published in order", func(t *testing.T) {
+		flow.steps = nil
+		ctx.PublishEvent(&OrderPlacedEvent{})
+		require.Equal(t, []string{"placed", "payment", "receipt", "shipping"}, flow.steps)
+	})
+
+	t.Run("returned error fails listener", func(t *testing.T) {
+		flow.steps = nil
+		requirePanicsWithCause(t, errInvalidOrder, func() {
+			ctx.PublishEvent(&OrderPlacedEvent{invalid: true})
+		})
+		require.Empty(t, flow.steps)
+	})
+}
+
+type CountingMulticaster struct {
+	ioc.SimpleApplicationEventMulticaster
+	multicasted atomic.Int32
+}
+
+func (this *CountingMulticaster) MulticastEvent(event any, listeners []*ioc.ListenerInvoker, errorHandler ioc.ErrorHandler) {
+	this.multicasted.Add(1)
+	this.SimpleApplicationEventMulticaster.MulticastEvent(event, listeners, errorHandler)
+}
+
+// Propagates failures unless collecting is enabled by the test
+type SwitchableErrorHandler struct {
+	collecting atomic.Pointer[ioc.CollectingErrorHandler]
+}
+
+func (this *SwitchableErrorHandler) HandleError(e error) {
+	if collecting := this.collecting.Load(); collecting != nil {
+		collecting.HandleError(e)
+	} else {
+		ioc.PropagatingErrorHandler{}.HandleError(e)
+	}
+}
+
+// Implements ErrorHandler without being registered under the reserved name
+type AuditErrorHandler struct {
+	handled atomic.Int32
+}
+
+func (this *AuditErrorHandler) HandleError(e error) {
+	this.handled.Add(1)
+}
+
+func Test_IocEventMulticasterAndErrorHandler(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+
+	t.Run("multicaster bean dispatches events", func(t *testing.T) {
+		multicaster := ioc.MustResolve[*CountingMulticaster]()
+		multicasted := multicaster.multicasted.Load()
+		ctx.PublishEvent(&PaymentEvent{})
+		require.Equal(t, multicasted+1, multicaster.multicasted.Load())
+	})
+
+	t.Run("error handler bean collects failures", func(t *testing.T) {
+		handler := ioc.MustResolve[*SwitchableErrorHandler]()
+		collecting := ioc.NewCollectingErrorHandler()
+		handler.collecting.Store(collecting)
+		defer handler.collecting.Store(nil)
+
+		ctx.PublishEvent(&OrderPlacedEvent{invalid: true})
+		ctx.PublishEvent(&OrderPlacedEvent{invalid: true})
+		require.ErrorIs(t, collecting.Err(), errInvalidOrder)
+		require.Len(t, collecting.Err().(interface{ Unwrap() []error }).Unwrap(), 2)
+	})
+
+	t.Run("beans implementing the interface are not picked without the reserved name", func(t *testing.T) {
+		audit := ioc.MustResolve[*AuditErrorHandler]()
+		requirePanicsWithCause(t, errInvalidOrder, func() {
+			ctx.PublishEvent(&OrderPlacedEvent{invalid: true})
+		})
+		require.Zero(t, audit.handled.Load())
+	})
+}
+
+type DeployEvent struct {
+	version string
+}
+
+type DeployWatcher struct {
+	versions []string
+}
+
+var _ ioc.ApplicationListener[*DeployEvent] = (*DeployWatcher)(nil)
+
+func (this *DeployWatcher) OnApplicationEvent(event *DeployEvent) {
+	this.versions = append(this.versions, event.version)
+}
+
+func Test_IocFunctionAndInterfaceListeners(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	watcher := ioc.MustResolve[*DeployWatcher]()
+	var deployed []string
+	ioc.OnEvent(func(event *DeployEvent) {
+		deployed = append(deployed, event.version)
+	})
+
+	ctx.PublishEvent(&DeployEvent{version: "1.0.1"})
+	require.Equal(t, []string{"1.0.1"}, watcher.versions)
+	require.Equal(t, []string{"1.0.1"}, deployed)
+}
+
+type ReadinessGate struct {
+	ready bool
+}
+
+func (this *ReadinessGate) OnReady(event *ioc.ApplicationReadyEvent) {
+	this.ready = true
+}
+
+type MigrationEvent struct{}
+
+type MigrationJob struct {
+	migrated int
+}
+
+func (this *MigrationJob) OnMigration(event *MigrationEvent) {
+	this.migrated++
+}
+
+func Test_IocLateListeners(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+
+	t.Run("sticky event replayed to bean created later", func(t *testing.T) {
+		ctx.PublishEvent(ioc.NewApplicationReadyEvent(time.Second))
+		require.True(t, ioc.MustResolve[*ReadinessGate]().ready)
+	})
+
+	t.Run("lazy bean created on first matching event", func(t *testing.T) {
+		require.False(t, ctx.BeanDefinition("migrationJob").Instantiated)
+		ctx.PublishEvent(&MigrationEvent{})
+		require.True(t, ctx.BeanDefinition("migrationJob").Instantiated)
+		ctx.PublishEvent(&MigrationEvent{})
+		require.Equal(t, 2, ioc.MustResolve[*MigrationJob]().migrated)
+	})
+}
+
+type TickEvent struct {
+	n int
+}
+
+func Test_IocSubscribe(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+
+	t.Run("block", func(t *testing.T) {
+		events, cancel := ioc.Subscribe[*TickEvent](1)
+		defer cancel()
+		go func() {
+			for n := range 3 {
+				ctx.PublishEvent(&TickEvent{n: n})
+			}
+		}()
+		for n := range 3 {
+			require.Equal(t, n, (<-events).n)
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		events, cancel := ioc.Subscribe[*TickEvent](2, ioc.OverflowDropOldest)
+		defer cancel()
+		for n := range 3 {
+			ctx.PublishEvent(&TickEvent{n: n})
+		}
+		require.Equal(t, 1, (<-events).n)
+		require.Equal(t, 2, (<-events).n)
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		events, cancel := ioc.Subscribe[*TickEvent](2, ioc.OverflowDropNewest)
+		defer cancel()
+		for n := range 3 {
+			ctx.PublishEvent(&TickEvent{n: n})
+		}
+		require.Equal(t, 0, (<-events).n)
+		require.Equal(t, 1, (<-events).n)
+	})
+
+	t.Run("cancel closes channel and unblocks publisher", func(t *testing.T) {
+		events, cancel := ioc.Subscribe[*TickEvent](0)
+		published := make(chan struct{})
+		go func() {
+			ctx.PublishEvent(&TickEvent{})
+			close(published)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		<-published
+		_, ok := <-events
+		require.False(t, ok)
+		ctx.PublishEvent(&TickEvent{})
+	})
+}
+
+type traceKey struct{}
+
+type TracedEvent struct{}
+
+type TraceListener struct {
+	traceID string
+	async   atomic.Int32
+}
+
+func (this *TraceListener) OnTraced(ctx context.Context, event *TracedEvent) {
+	this.traceID, _ = ctx.Value(traceKey{}).(string)
+}
+
+func (this *TraceListener) OnTracedAsync(ctx context.Context, event *TracedEvent) {
+	this.async.Add(1)
+}
+
+func Test_IocPublishEventCtx(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	listener := ioc.MustResolve[*TraceListener]()
+
+	t.Run("context passed to listeners", func(t *testing.T) {
+		var functionTraceID string
+		ioc.OnEvent(func(ctx context.Context, event *TracedEvent) {
+			functionTraceID, _ = ctx.Value(traceKey{}).(string)
+		})
+		ctx.PublishEventCtx(context.WithValue(context.Background(), traceKey{}, "trace-1"), &TracedEvent{})
+		require.Equal(t, "trace-1", listener.traceID)
+		require.Equal(t, "trace-1", functionTraceID)
+		require.Eventually(t, func() bool { return listener.async.Load() == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("async listener skipped after cancellation", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "trace-2"))
+		cancel()
+		ctx.PublishEventCtx(canceled, &TracedEvent{})
+		require.Equal(t, "trace-2", listener.traceID)
+		require.Never(t, func() bool { return listener.async.Load() != 1 }, 50*time.Millisecond, time.Millisecond)
+	})
+}
+
+func Test_IocRecordEvents(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	events := iocttest.RecordEvents(t)
+
+	ctx.Stop()
+	ctx.Start()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctx.PublishEventCtx(context.WithValue(context.Background(), traceKey{}, "trace-3"), &DeployEvent{version: "2.0.0"})
+	}()
+	<-done
+	ctx.PublishEvent(&DeployEvent{version: "2.0.1"})
+
+	deployed := iocttest.OfType[*DeployEvent](events)
+	require.Len(t, deployed, 2)
+	require.Equal(t, "2.0.0", deployed[0].version)
+	require.Equal(t, "2.0.1", deployed[1].version)
+	require.Len(t, iocttest.OfType[*ioc.ContextStoppedEvent](events), 1)
+	require.Len(t, iocttest.OfType[*ioc.ContextStartedEvent](events), 1)
+
+	recorded := events.All()
+	require.Len(t, recorded, 4)
+	require.IsType(t, &ioc.ContextStoppedEvent{}, recorded[0].Event)
+	require.Equal(t, 3, recorded[3].Sequence)
+	require.Equal(t, "trace-3", recorded[2].Context.Value(traceKey{}))
+	require.NotEqual(t, recorded[2].Goroutine, recorded[3].Goroutine)
+	require.Equal(t, recorded[0].Goroutine, recorded[3].Goroutine)
+}
+
+type DispatchEvent struct {
+	steps []string
+}
+
+type ShippingListener struct{}
+
+func (this *ShippingListener) Reserve(event *DispatchEvent) {
+	event.steps = append(event.steps, "reserve")
+}
+
+func (this *ShippingListener) Ship(event *DispatchEvent) {
+	event.steps = append(event.steps, "ship")
+}
+
+type BillingListener struct{}
+
+func (this *BillingListener) Charge(event *DispatchEvent) {
+	event.steps = append(event.steps, "charge")
+}
+
+func Test_IocListenerOrder(t *testing.T) {
+	ctx := ioc.MustResolve[*ContextHolder]().ctx
+	ioc.MustResolve[*ShippingListener]()
+	ioc.MustResolve[*BillingListener]()
+	ioc.OnEvent(func(event *DispatchEvent) {
+		event.steps = append(event.steps, "notify")
+	})
+	ioc.OnEvent(func(event *DispatchEvent) {
+		event.steps = append(event.steps, "validate")
+	}, ioc.ListenerOrder(-20))
+
+	event := &DispatchEvent{}
+	ctx.PublishEvent(event)
+	require.Equal(t, []string{"validate", "reserve", "charge", "ship", "notify"}, event.steps)
+}
+
+const isolatedTestEnv = "IOC_ISOLATED_TEST"
+
+// isolated reports whether the test runs in a child process with an empty
+// ApplicationContext. Otherwise the child process is started and its output
+// and exit error are returned.
+func isolated(t *testing.T) (bool, string, error) {
+	if os.Getenv(isolatedTestEnv) == t.Name() {
+		return true, "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	command := exec.CommandContext(ctx, os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+	command.Env = append(os.Environ(), isolatedTestEnv+"="+t.Name())
+	output, e := command.CombinedOutput()
+	return false, string(output), e
+}
+
+func Test_IocAsyncEventsDrainTimeout(t *testing.T) {
+	child, output, e := isolated(t)
+	if !child {
+		require.NoError(t, e, output)
+		require.Contains(t, output, "async events not delivered within 100ms, abandoning 1: *ioc_test.UserCreatedEvent for function listener")
+		require.Contains(t, output, "context closed")
+		return
+	}
+	env.SetActiveProfiles("test").WithPropertySource(env.MapPropertySourceOfMap("test", map[string]string{
+		"ioc.async-events.drain-timeout": "100ms",
+	}))
+	stuck := make(chan struct{})
+	ioc.OnEvent(func(event *UserCreatedEvent) {
+		<-stuck
+	}, ioc.Async())
+	ioc.Bean[*ContextHolder]().Factory(func() *ContextHolder { return &ContextHolder{} }).Register()
+	ioc.MustResolve[*ContextHolder]().ctx.PublishEvent(&UserCreatedEvent{})
+}
+
+func Test_IocCloseWithBlockedSubscriber(t *testing.T) {
+	child, output, e := isolated(t)
+	if !child {
+		require.NoError(t, e, output)
+		require.Contains(t, output, "context closed")
+		return
+	}
+	// never read, ContextClosedEvent must not block ioc.Close()
+	ioc.Subscribe[*ioc.ContextClosedEvent](0)
+}
+
+type Greeting struct {
+	text string
+}
+
+func Test_IocDuplicateBeanNames(t *testing.T) {
+	child, output, e := isolated(t)
+	if !child {
+		require.Error(t, e, output)
+		require.Contains(t, output, "Bean with name 'greeting' already registered")
+		require.Contains(t, output, "Context refresh failed.")
+		require.Contains(t, output, "context closed")
+		return
+	}
+	ioc.Bean[*Greeting]().Name("greeting").Factory(func() *Greeting { return &Greeting{"hello"} }).Register()
+	ioc.Bean[*Greeting]().Name("greeting").Factory(func() *Greeting { return &Greeting{"hi"} }).Register()
+	ioc.Refresh()
+}
+
+func Test_IocProfilesReevaluatedBeforeRefresh(t *testing.T) {
+	child, output, e := isolated(t)
+	if !child {
+		require.NoError(t, e, output)
+		return
+	}
+	ioc.Bean[*Greeting]().Name("greeting").Profile("en").Factory(func() *Greeting { return &Greeting{"hello"} }).Register()
+	ioc.Bean[*Greeting]().Name("greeting").Profile("de").Factory(func() *Greeting { return &Greeting{"hallo"} }).Register()
+
+	env.SetActiveProfiles("en")
+	require.Equal(t, []string{"greeting"}, ioc.BeanNamesForType[*Greeting]())
+	env.SetActiveProfiles("de")
+	ioc.Refresh()
+	require.Equal(t, "hallo", ioc.MustResolve[*Greeting]().text)
+}
+
+type ContextHolder struct {
+	ctx *ioc.ApplicationContext
+}
+
+// Implements ioc.ApplicationContextAware
+func (this *ContextHolder) SetApplicationContext(ctx *ioc.ApplicationContext) {
+	this.ctx = ctx
+}
+
+func Test_IocIntrospection(t *testing.T) {
+	t.Run("bean definitions inspected", func(t *testing.T) {
+		ctx := ioc.MustResolve[*ContextHolder]().ctx
+		ioc.MustResolve[*Counter]("singletonCounter")
+
+		require.True(t, ctx.ContainsBean("counter"))
+		require.False(t, ctx.ContainsBean("missingCounter"))
+		require.True(t, ctx.IsSingleton("singletonCounter"))
+		require.False(t, ctx.IsSingleton("prototypeCounter"))
+		require.Equal(t, reflect.TypeFor[*DivideOperation](), ctx.TypeOf("divideOperation"))
+		require.ElementsMatch(t, []string{"addOperation", "subtractOperation", "multiplyOperation", "divideOperation"}, ioc.BeanNamesForType[Operation]())
+
+		descriptor := ctx.BeanDefinition("divideOperation")
+		require.Equal(t, ioc.Singleton, descriptor.Scope)
+		require.Equal(t, 0, *descriptor.Order)
+		require.True(t, ctx.BeanDefinition("counter").Instantiated)
+		require.False(t, ctx.BeanDefinition("prototypeCounter").Instantiated)
+
+		definitions := ctx.BeanDefinitions()
+		require.Equal(t, reflect.TypeFor[*Counter](), definitions[0].Type)
+		require.Equal(t, []string{"singletonCounter", "counter"}, definitions[0].Names)
+	})
+}
+
+func Test_IocConditionEvaluationReport(t *testing.T) {
+	t.Run("skipped bean definitions reported", func(t *testing.T) {
+		report := ioc.MustResolve[*ContextHolder]().ctx.ConditionEvaluationReport()
+
+		skipped := report.Skipped()
+		require.Equal(t, 1, len(skipped))
+		require.Equal(t, reflect.TypeFor[*MockCalculator](), skipped[0].Type)
+		require.Equal(t, []string{"!test"}, skipped[0].Profiles)
+		require.Contains(t, report.String(), "skipped  *ioc_test.MockCalculator [singleton primary]: profile '!test' did not match active profiles [default test]")
+
+		_, e := ioc.ResolveE[*MockCalculator]()
+		require.ErrorContains(t, errors.Unwrap(e), "No bean of type *ioc_test.MockCalculator found\nSkipped *ioc_test.MockCalculator")
+	})
+}
+
+type PingEvent struct{}
+
+type PingListener struct {
+	pings atomic.Int32
+}
+
+func (this *PingListener) OnPing(event *PingEvent) {
+	this.pings.Add(1)
+}
+
+type ConcurrentService struct {
+	calculator Calculator `inject:""`
+	pings      atomic.Int32
+}
+
+func (this *ConcurrentService) OnPing(event *PingEvent) {
+	this.pings.Add(1)
+}
+
+func Test_IocConcurrentResolveAndPublish(t *testing.T) {
+	t.Run("resolve and publish from many goroutines", func(t *testing.T) {
+		ctx := ioc.MustResolve[*ContextHolder]().ctx
+		listener := ioc.MustResolve[*PingListener]()
+		pings := listener.pings.Load()
+
+		var wg sync.WaitGroup
+		for i := range 32 {
+			wg.Go(func() {
+				ioc.MustResolve[*ConcurrentService](fmt.Sprintf("concurrentService%d", i%3+1))
+				ioc.MustResolve[*Counter]("prototypeCounter")
+				ctx.PublishEvent(&PingEvent{})
+				ctx.BeanDefinitions()
+			})
+		}
+		wg.Wait()
+
+		require.Equal(t, pings+32, listener.pings.Load())
+		for _, name := range []string{"concurrentService1", "concurrentService2", "concurrentService3"} {
+			require.NotNil(t, ioc.MustResolve[*ConcurrentService](name).calculator)
+		}
+	})
+}
+
+type WarmCache struct {
+	warm atomic.Bool
+}
+
+func Test_IocConcurrentResolveWaitsForInitialization(t *testing.T) {
+	var wg sync.WaitGroup
+	var cold atomic.Int32
+	for range 8 {
+		wg.Go(func() {
+			if !ioc.MustResolve[*WarmCache]().warm.Load() {
+				cold.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	require.Zero(t, cold.Load(), "goroutines resolved the bean before PostConstruct completed")
+}
+
+type LeftNode struct {
+	right *RightNode `inject:""`
+}
+
+type RightNode struct {
+	left *LeftNode `inject:""`
+}
+
+func Test_IocConcurrentCircularReference(t *testing.T) {
+	var wg sync.WaitGroup
+	var left *LeftNode
+	var right *RightNode
+	wg.Go(func() { left = ioc.MustResolve[*LeftNode]() })
+	wg.Go(func() { right = ioc.MustResolve[*RightNode]() })
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "circular pair resolved from two goroutines deadlocked")
+	}
+	require.Same(t, right, left.right)
+	require.Same(t, left, right.left)
+}
+
+var errConnectionRefused = errors.New("connection refused")
+var errInvalidCounter = errors.New("invalid counter")
+
+type UnreachableClient struct{}
+
+func Test_IocErrorReturningCallbacks(t *testing.T) {
+	t.Run("factory and post construct errors fail bean creation", func(t *testing.T) {
+		requirePanicsWithCause(t, errConnectionRefused, func() {
+			ioc.InjectBeans(&struct {
+				client *UnreachableClient `inject:""`
+			}{})
+		})
+		for range 2 {
+			requirePanicsWithCause(t, errInvalidCounter, func() {
+				ioc.InjectBeans(&struct {
+					counter *Counter `inject:"invalidCounter"`
+				}{})
+			})
+		}
+	})
+	t.Run("nil callbacks rejected on definition", func(t *testing.T) {
+		require.PanicsWithError(t, "Bean factory method must be provided", func() {
+			ioc.Bean[*Counter]().Factory(nil)
+		})
+		require.PanicsWithError(t, "Bean factory method must be provided", func() {
+			ioc.Bean[*Counter]().FactoryE(nil)
+		})
+		require.PanicsWithError(t, "Bean factory method must be provided", func() {
+			ioc.Bean[*Counter]().FactoryContext(nil)
+		})
+		require.PanicsWithError(t, "PostConstruct method must be provided", func() {
+			ioc.Bean[*Counter]().PostConstruct(nil)
+		})
+		require.PanicsWithError(t, "PostConstruct method must be provided", func() {
+			ioc.Bean[*Counter]().PostConstructE(nil)
+		})
+		require.PanicsWithError(t, "PreDestroy method must be provided", func() {
+			ioc.Bean[*Counter]().PreDestroy(nil)
+		})
+		require.PanicsWithError(t, "PreDestroy method must be provided", func() {
+			ioc.Bean[*Counter]().PreDestroyE(nil)
+		})
+	})
+}
+
+func requirePanicsWithCause(t *testing.T, cause error, f func()) {
+	defer func() {
+		e := recover()
+		require.NotNil(t, e)
+		require.ErrorIs(t, e.(error), cause)
+	}()
+	f()
+}
+
+type CalculatorDeps struct {
+	calculator Calculator `inject:""`
+}
+
+type NestedConsumer struct {
+	CalculatorDeps
+	*NestedConsumer
+	operations struct {
+		add    Operation `inject:"addOperation"`
+		divide Operation `inject:"divideOperation"`
+	} `inject:""`
+	deps CalculatorDeps `inject:",group"`
+}
+
+type MailConfig struct {
+	host string
+}
+
 type Counter struct {
 	count int
 }
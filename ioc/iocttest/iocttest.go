@@ -0,0 +1,74 @@
+// Package iocttest provides utilities for testing code publishing
+// application events.
+package iocttest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-beans/go/ioc"
+	"github.com/go-jang/go/util/concurrent"
+)
+
+// Event recorded by RecordEvents
+type RecordedEvent struct {
+	ioc.PublishedEvent
+	Sequence int // publication order, starting at 0
+	Time     time.Time
+}
+
+// Events published since RecordEvents
+type Events struct {
+	recorded []RecordedEvent
+	mutex    sync.Mutex
+}
+
+// RecordEvents records every event published until the test ends,
+// including lifecycle events:
+//
+//	events := iocttest.RecordEvents(t)
+//	service.CreateUser("alice")
+//	require.Len(t, iocttest.OfType[*UserCreatedEvent](events), 1)
+func RecordEvents(t testing.TB) *Events {
+	t.Helper()
+	events := &Events{}
+	remove := ioc.OnPublish(func(published ioc.PublishedEvent) {
+		concurrent.Synchronized(&events.mutex, func() {
+			events.recorded = append(events.recorded, RecordedEvent{
+				PublishedEvent: published,
+				Sequence:       len(events.recorded),
+				Time:           time.Now(),
+			})
+		})
+	})
+	t.Cleanup(remove)
+	return events
+}
+
+// All returns recorded events in publication order
+func (this *Events) All() []RecordedEvent {
+	var recorded []RecordedEvent
+	concurrent.Synchronized(&this.mutex, func() {
+		recorded = append(recorded, this.recorded...)
+	})
+	return recorded
+}
+
+// Reset discards recorded events
+func (this *Events) Reset() {
+	concurrent.Synchronized(&this.mutex, func() {
+		this.recorded = nil
+	})
+}
+
+// OfType returns recorded events assignable to E in publication order
+func OfType[E any](events *Events) []E {
+	var matching []E
+	for _, recorded := range events.All() {
+		if event, ok := recorded.Event.(E); ok {
+			matching = append(matching, event)
+		}
+	}
+	return matching
+}